@@ -0,0 +1,333 @@
+package goweb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditEntry records who did what to which resource, kept apart from the access log so it can be retained longer and queried on its own.
+type AuditEntry struct {
+	Time      time.Time
+	RequestID string
+	UserID    string
+	IP        string
+	Method    string
+	Path      string
+	Status    int
+	// Action names the operation, e.g. "order.cancel".
+	Action string
+	// Target identifies the resource acted upon, e.g. "order/42".
+	Target string
+	// Before and After summarize the resource around the change.
+	Before string
+	After  string
+}
+
+// AuditFilter selects audit entries, zero fields match everything.
+type AuditFilter struct {
+	UserID string
+	Action string
+	Target string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+func (f AuditFilter) match(e AuditEntry) bool {
+	return (f.UserID == "" || e.UserID == f.UserID) &&
+		(f.Action == "" || e.Action == f.Action) &&
+		(f.Target == "" || e.Target == f.Target) &&
+		(f.Since.IsZero() || !e.Time.Before(f.Since)) &&
+		(f.Until.IsZero() || e.Time.Before(f.Until))
+}
+
+// AuditStore persists audit entries.
+type AuditStore interface {
+	SaveAudit(e AuditEntry) error
+	// QueryAudit returns the matching entries, newest first.
+	QueryAudit(f AuditFilter) ([]AuditEntry, error)
+	// PurgeAudit deletes the entries older than before, it is how the retention period is enforced.
+	PurgeAudit(before time.Time) (int, error)
+}
+
+type auditRecord struct {
+	action string
+	target string
+	before string
+	after  string
+}
+
+// Audit returns a middleware writing an audit entry for mutating requests (POST, PUT, PATCH and DELETE) to the routes it is added to.
+// The handler describes the change with AuditTarget and AuditChange; requests that fail with a status of 400 or above are recorded too,
+// as are requests whose handlers panic, with status 500 unless one was already sent.
+func Audit(store AuditStore, action string) HandlerFunc {
+	return func(c *Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			return
+		}
+		c.audit = &auditRecord{action: action}
+		completed := false
+		defer func() {
+			status := c.StatusCode
+			if status == 0 && !completed {
+				status = http.StatusInternalServerError
+			} else if status == 0 {
+				status = http.StatusOK
+			}
+			c.saveAudit(store, status)
+		}()
+		c.Next()
+		completed = true
+	}
+}
+
+func (c *Context) saveAudit(store AuditStore, status int) {
+	e := AuditEntry{
+		Time:      c.Engine.Clock.Now(),
+		RequestID: c.RequestID,
+		UserID:    c.UserID(),
+		IP:        c.ClientIP(),
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		Status:    status,
+		Action:    c.audit.action,
+		Target:    c.audit.target,
+		Before:    c.audit.before,
+		After:     c.audit.after,
+	}
+	if err := store.SaveAudit(e); err != nil {
+		c.Logger().Println("audit:", err)
+	}
+}
+
+// AuditTarget sets the resource the audited request acts upon, it does nothing outside of an Audit route.
+func (c *Context) AuditTarget(target string) {
+	if c.audit != nil {
+		c.audit.target = target
+	}
+}
+
+// AuditChange sets summaries of the resource before and after the audited request.
+func (c *Context) AuditChange(before, after string) {
+	if c.audit != nil {
+		c.audit.before, c.audit.after = before, after
+	}
+}
+
+// AuditAction overrides the action passed to Audit, for routes performing more than one kind of operation.
+func (c *Context) AuditAction(action string) {
+	if c.audit != nil {
+		c.audit.action = action
+	}
+}
+
+// MemoryAuditStore keeps audit entries in memory, useful for tests and single instance deployments.
+type MemoryAuditStore struct {
+	mu      sync.Mutex
+	entries []AuditEntry
+}
+
+func (s *MemoryAuditStore) SaveAudit(e AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, e)
+	return nil
+}
+
+func (s *MemoryAuditStore) QueryAudit(f AuditFilter) ([]AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []AuditEntry
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		if f.Limit > 0 && len(result) == f.Limit {
+			break
+		}
+		if f.match(s.entries[i]) {
+			result = append(result, s.entries[i])
+		}
+	}
+	return result, nil
+}
+
+func (s *MemoryAuditStore) PurgeAudit(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.entries[:0]
+	for _, e := range s.entries {
+		if !e.Time.Before(before) {
+			kept = append(kept, e)
+		}
+	}
+	n := len(s.entries) - len(kept)
+	s.entries = kept
+	return n, nil
+}
+
+// SQLAuditStore keeps audit entries in a database table, created by CreateTable.
+type SQLAuditStore struct {
+	DB    *sql.DB
+	Table string
+	// Placeholder returns the bind parameter for the nth argument, counting from 1. It defaults to "?", use "$n" for PostgreSQL.
+	Placeholder func(n int) string
+}
+
+func NewSQLAuditStore(db *sql.DB) *SQLAuditStore {
+	return &SQLAuditStore{DB: db, Table: "audit_log"}
+}
+
+func (s *SQLAuditStore) bind(n int) string {
+	if s.Placeholder == nil {
+		return "?"
+	}
+	return s.Placeholder(n)
+}
+
+func (s *SQLAuditStore) CreateTable() error {
+	_, err := s.DB.Exec(`CREATE TABLE IF NOT EXISTS ` + s.Table + ` (
+	created_at TIMESTAMP NOT NULL,
+	request_id VARCHAR(64) NOT NULL,
+	user_id VARCHAR(255) NOT NULL,
+	ip VARCHAR(64) NOT NULL,
+	method VARCHAR(16) NOT NULL,
+	path TEXT NOT NULL,
+	status INTEGER NOT NULL,
+	action VARCHAR(255) NOT NULL,
+	target VARCHAR(255) NOT NULL,
+	before_summary TEXT NOT NULL,
+	after_summary TEXT NOT NULL
+)`)
+	return err
+}
+
+func (s *SQLAuditStore) SaveAudit(e AuditEntry) error {
+	var params []string
+	for i := 1; i <= 11; i++ {
+		params = append(params, s.bind(i))
+	}
+	_, err := s.DB.Exec(`INSERT INTO `+s.Table+` (created_at, request_id, user_id, ip, method, path, status, action, target, before_summary, after_summary) VALUES (`+strings.Join(params, ", ")+`)`,
+		e.Time, e.RequestID, e.UserID, e.IP, e.Method, e.Path, e.Status, e.Action, e.Target, e.Before, e.After)
+	return err
+}
+
+func (s *SQLAuditStore) QueryAudit(f AuditFilter) ([]AuditEntry, error) {
+	var where []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		where = append(where, cond+" "+s.bind(len(args)))
+	}
+	if f.UserID != "" {
+		add("user_id =", f.UserID)
+	}
+	if f.Action != "" {
+		add("action =", f.Action)
+	}
+	if f.Target != "" {
+		add("target =", f.Target)
+	}
+	if !f.Since.IsZero() {
+		add("created_at >=", f.Since)
+	}
+	if !f.Until.IsZero() {
+		add("created_at <", f.Until)
+	}
+	query := `SELECT created_at, request_id, user_id, ip, method, path, status, action, target, before_summary, after_summary FROM ` + s.Table
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at DESC"
+	if f.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", f.Limit)
+	}
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.Time, &e.RequestID, &e.UserID, &e.IP, &e.Method, &e.Path, &e.Status, &e.Action, &e.Target, &e.Before, &e.After); err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
+
+func (s *SQLAuditStore) PurgeAudit(before time.Time) (int, error) {
+	res, err := s.DB.Exec(`DELETE FROM `+s.Table+` WHERE created_at < `+s.bind(1), before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// RetainAudit purges audit entries older than keep from store every interval, until the engine shuts down.
+func (engine *Engine) RetainAudit(store AuditStore, keep, interval time.Duration) {
+	done := make(chan struct{})
+	engine.OnShutdown(func(ctx context.Context) { close(done) })
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if n, err := store.PurgeAudit(engine.Clock.Now().Add(-keep)); err != nil {
+				engine.Logger.Println("audit retention:", err)
+			} else if n > 0 {
+				engine.Logger.Println("audit retention: purged", n, "entries")
+			}
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+const maxAuditLimit = 1000
+
+// AuditHandler returns a handler answering audit queries with the user_id, action, target, since, until and limit query parameters,
+// since and until in RFC 3339. limit defaults to 100 and is capped at maxAuditLimit. Mount it behind an authorization check.
+func AuditHandler(store AuditStore) HandlerFunc {
+	return func(c *Context) {
+		q := c.Request.URL.Query()
+		f := AuditFilter{UserID: q.Get("user_id"), Action: q.Get("action"), Target: q.Get("target"), Limit: 100}
+		for name, t := range map[string]*time.Time{"since": &f.Since, "until": &f.Until} {
+			if v := q.Get(name); v != "" {
+				parsed, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid %s: %v", name, err))
+					return
+				}
+				*t = parsed
+			}
+		}
+		if v := q.Get("limit"); v != "" {
+			limit, err := strconv.Atoi(v)
+			if err != nil || limit <= 0 {
+				c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid limit %q", v))
+				return
+			}
+			if limit > maxAuditLimit {
+				limit = maxAuditLimit
+			}
+			f.Limit = limit
+		}
+		entries, err := store.QueryAudit(f)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Success(entries)
+	}
+}
@@ -25,6 +25,12 @@ import (
 
 var access_token_cookie_name string
 
+// Events emitted on the goweb Context, the payload is the session claims.
+const (
+	EventLogin  = "auth.login"
+	EventLogout = "auth.logout"
+)
+
 const csrf_state_cookie_name = "crft_state"
 const pkce_cookie_name = "pkce"
 
@@ -72,6 +78,7 @@ func Login(ctx *goweb.Context, token *oauth2.Token, jwk_json_url string, expire_
 	}
 	sessions = append(sessions, session)
 	http.SetCookie(ctx.Writer, &cookie)
+	ctx.Emit(EventLogin, session.Claims)
 	return &session
 }
 func Logout(rac *common.RestApiClient, ctx *goweb.Context, conf *oauth2.Config, introspectTokenURL string, skip_tls_verify bool, postLogout func(id_token string)) {
@@ -80,6 +87,7 @@ func Logout(rac *common.RestApiClient, ctx *goweb.Context, conf *oauth2.Config,
 	if err != nil {
 		panic(err)
 	}
+	ctx.Emit(EventLogout, s.Claims)
 	postLogout(s.token.Extra("id_token").(string))
 }
 
@@ -135,7 +143,7 @@ func GetSessionByToken(rac *common.RestApiClient, ctx *goweb.Context, conf *oaut
 			if err != nil {
 				return nil, err
 			}
-			ok, _, err := CheckToken(rac, token, introspectTokenURL, skip_tls_verify)
+			ok, sub, err := CheckToken(rac, token, introspectTokenURL, skip_tls_verify)
 			if err != nil {
 				return nil, err
 			}
@@ -143,6 +151,7 @@ func GetSessionByToken(rac *common.RestApiClient, ctx *goweb.Context, conf *oaut
 				removeSessionAt(i)
 				return nil, errors.New("the login session has expired.")
 			}
+			ctx.SetUserID(sub)
 			return s, nil
 		}
 	}
@@ -0,0 +1,81 @@
+package goweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+)
+
+// BannerText and BannerJSON are the formats of Engine.Banner.
+const (
+	BannerText = "text"
+	BannerJSON = "json"
+)
+
+type startupBanner struct {
+	Listen     []string    `json:"listen"`
+	TLS        bool        `json:"tls"`
+	Middleware []string    `json:"middleware"`
+	Routes     []RouteInfo `json:"routes"`
+}
+
+// handlerName returns the name of the function behind h, e.g. main.showUser or main.main.func1 for closures.
+func handlerName(h HandlerFunc) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(h).Pointer()); f != nil {
+		return f.Name()
+	}
+	return "unknown"
+}
+
+func handlerNames(handlers HandlersChain) []string {
+	names := make([]string, len(handlers))
+	for i, h := range handlers {
+		names[i] = handlerName(h)
+	}
+	return names
+}
+
+// printBanner logs the listen addresses, the global middleware and the route table in the format of Engine.Banner.
+func (engine *Engine) printBanner(addrs []string, tls bool) {
+	global := append(HandlersChain{}, engine.beforeRouting...)
+	b := startupBanner{Listen: addrs, TLS: tls, Middleware: handlerNames(append(global, engine.Handlers...))}
+	b.Routes = engine.Routes()
+	if engine.Banner == BannerJSON {
+		j, err := json.Marshal(b)
+		if err != nil {
+			engine.Logger.Println(err)
+			return
+		}
+		engine.Logger.Println(string(j))
+		return
+	}
+	engine.Logger.Printf("listening on %s, tls %v", strings.Join(b.Listen, ", "), b.TLS)
+	engine.Logger.Printf("middleware: %s", strings.Join(b.Middleware, ", "))
+	var table bytes.Buffer
+	w := tabwriter.NewWriter(&table, 0, 4, 2, ' ', 0)
+	for _, r := range b.Routes {
+		method, path := r.Method, r.Path
+		if method == "" {
+			method = "*"
+		}
+		if path == "" {
+			path = "(matcher)"
+		}
+		middleware := ""
+		if n := len(r.Handlers); n > 1 {
+			middleware = strings.Join(r.Handlers[:n-1], ", ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", method, path, r.Handler, middleware)
+	}
+	w.Flush()
+	engine.Logger.Printf("%d routes:", len(b.Routes))
+	for _, line := range strings.Split(strings.TrimRight(table.String(), "\n"), "\n") {
+		if line != "" {
+			engine.Logger.Println("  " + strings.TrimRight(line, " "))
+		}
+	}
+}
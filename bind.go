@@ -0,0 +1,168 @@
+package goweb
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const defaultMaxMultipartMemory = 32 << 20
+
+// FormErrors maps a form field name to the error met while binding it.
+type FormErrors map[string]error
+
+func (fe FormErrors) Error() string {
+	var names []string
+	for name := range fe {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var msgs []string
+	for _, name := range names {
+		msgs = append(msgs, name+": "+fe[name].Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+var fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+
+// BindForm maps urlencoded and multipart form values onto the exported fields of the struct pointed to by dst.
+// The field name is taken from the `form` tag, falling back to the field name itself; a tag of "-" skips the field.
+// Conversion failures are collected per field and returned as FormErrors.
+func (c *Context) BindForm(dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("BindForm requires a pointer to a struct")
+	}
+	if strings.HasPrefix(c.Request.Header.Get("Content-Type"), "multipart/form-data") && c.Request.MultipartForm == nil {
+		if err := c.Request.ParseMultipartForm(defaultMaxMultipartMemory); err != nil {
+			return err
+		}
+	} else if err := c.Request.ParseForm(); err != nil {
+		return err
+	}
+	values := c.Request.Form
+	var files map[string][]*multipart.FileHeader
+	if c.Request.MultipartForm != nil {
+		files = c.Request.MultipartForm.File
+	}
+	fe := FormErrors{}
+	bindStruct(rv.Elem(), values, files, fe)
+	if len(fe) > 0 {
+		return fe
+	}
+	return nil
+}
+
+func bindStruct(v reflect.Value, values url.Values, files map[string][]*multipart.FileHeader, fe FormErrors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name := sf.Tag.Get("form")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fv := v.Field(i)
+		switch {
+		case fv.Type() == fileHeaderType:
+			if fhs := files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs[0]))
+			}
+		case fv.Kind() == reflect.Slice && fv.Type().Elem() == fileHeaderType:
+			if fhs := files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs))
+			}
+		case fv.Kind() == reflect.Struct && sf.Anonymous:
+			bindStruct(fv, values, files, fe)
+		case fv.Kind() == reflect.Slice:
+			vals, ok := values[name]
+			if !ok {
+				continue
+			}
+			slice := reflect.MakeSlice(fv.Type(), len(vals), len(vals))
+			for j, s := range vals {
+				if err := setValue(slice.Index(j), s); err != nil {
+					fe[name] = err
+					break
+				}
+			}
+			if fe[name] == nil {
+				fv.Set(slice)
+			}
+		default:
+			vals, ok := values[name]
+			if !ok || len(vals) == 0 {
+				continue
+			}
+			if err := setValue(fv, vals[0]); err != nil {
+				fe[name] = err
+			}
+		}
+	}
+}
+
+func setValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		p := reflect.New(v.Type().Elem())
+		if err := setValue(p.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		if s == "" || s == "on" {
+			v.SetBool(s == "on")
+			return nil
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a valid boolean", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid integer", s)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid unsigned integer", s)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid number", s)
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
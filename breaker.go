@@ -0,0 +1,95 @@
+package goweb
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// EventRouteTripped is emitted when PanicBreaker takes a route out of service, the payload is the route pattern.
+const EventRouteTripped = "route.tripped"
+
+// PanicBreaker takes a route out of service after it panicked Threshold times within Window, answering it with 503 for Cooldown,
+// so a crashing endpoint does not flood the logs and pay for recovery on every request.
+type PanicBreaker struct {
+	Threshold int
+	Window    time.Duration
+	Cooldown  time.Duration
+	// OnTrip is called when a route is taken out of service, e.g. to raise an alert.
+	OnTrip func(c *Context, route string, panics int)
+
+	mu     sync.Mutex
+	routes map[*node]*breakerState
+}
+
+type breakerState struct {
+	panics    []time.Time
+	openUntil time.Time
+}
+
+func NewPanicBreaker(threshold int, window, cooldown time.Duration) *PanicBreaker {
+	return &PanicBreaker{Threshold: threshold, Window: window, Cooldown: cooldown}
+}
+
+// allow answers the request with 503 and returns false while the route of c is out of service.
+func (b *PanicBreaker) allow(c *Context) bool {
+	if b == nil || c.route == nil {
+		return true
+	}
+	now := c.Engine.Clock.Now()
+	b.mu.Lock()
+	s := b.routes[c.route]
+	var until time.Time
+	if s != nil {
+		until = s.openUntil
+	}
+	b.mu.Unlock()
+	if !until.After(now) {
+		return true
+	}
+	c.Writer.Header().Set("Retry-After", strconv.Itoa(int(until.Sub(now)/time.Second)+1))
+	c.AbortWithError(http.StatusServiceUnavailable, errors.New("temporarily unavailable"))
+	return false
+}
+
+// recordPanic counts a panic of the route of c and trips the breaker once Threshold is reached.
+func (b *PanicBreaker) recordPanic(c *Context) {
+	if b == nil || c.route == nil {
+		return
+	}
+	now := c.Engine.Clock.Now()
+	b.mu.Lock()
+	if b.routes == nil {
+		b.routes = map[*node]*breakerState{}
+	}
+	s := b.routes[c.route]
+	if s == nil {
+		s = &breakerState{}
+		b.routes[c.route] = s
+	}
+	panics := s.panics[:0]
+	for _, t := range s.panics {
+		if now.Sub(t) < b.Window {
+			panics = append(panics, t)
+		}
+	}
+	s.panics = append(panics, now)
+	tripped := len(s.panics) >= b.Threshold
+	count := len(s.panics)
+	if tripped {
+		s.openUntil = now.Add(b.Cooldown)
+		s.panics = nil
+	}
+	b.mu.Unlock()
+	if !tripped {
+		return
+	}
+	route := c.route.pattern()
+	c.Engine.Logger.Printf("route %s panicked %d times, out of service for %s", route, count, b.Cooldown)
+	if b.OnTrip != nil {
+		b.OnTrip(c, route, count)
+	}
+	c.Emit(EventRouteTripped, route)
+}
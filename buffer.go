@@ -0,0 +1,133 @@
+package goweb
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type BufferOptions struct {
+	// MaxSize is the number of bytes buffered before the response falls back to streaming.
+	MaxSize int
+	// ETag sets a strong ETag computed from the body and answers matching If-None-Match requests with 304.
+	ETag bool
+}
+
+type responseBuffer struct {
+	w      *ResponseWriter
+	opts   BufferOptions
+	status int
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) write(p []byte) (int, error) {
+	if b.body.Len()+len(p) > b.opts.MaxSize {
+		b.w.flushBuffer()
+		return b.w.Write(p)
+	}
+	return b.body.Write(p)
+}
+
+// BufferResponse returns a middleware holding back the response of the handlers after it until they return,
+// so a Content-Length and, optionally, an ETag can be sent. Responses larger than MaxSize are streamed as usual.
+func BufferResponse(opts BufferOptions) HandlerFunc {
+	return func(c *Context) {
+		if c.Writer.Written() || c.Writer.buf != nil {
+			return
+		}
+		c.Writer.buf = &responseBuffer{w: c.Writer, opts: opts}
+		c.Next()
+		buf := c.Writer.buf
+		if buf == nil {
+			return
+		}
+		status := buf.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		h := c.Writer.Header()
+		transformers := append(append([]HTMLTransformer{}, c.Engine.HTMLTransformers...), c.htmlTransformers...)
+		if len(transformers) > 0 && buf.body.Len() > 0 && isHTML(h, buf.body.Bytes()) {
+			html := buf.body.Bytes()
+			for _, transform := range transformers {
+				html = transform(c, html)
+			}
+			buf.body.Reset()
+			buf.body.Write(html)
+		}
+		if opts.ETag && status == http.StatusOK && h.Get("ETag") == "" {
+			sum := sha1.Sum(buf.body.Bytes())
+			etag := `"` + hex.EncodeToString(sum[:]) + `"`
+			h.Set("ETag", etag)
+			if c.Request.Header.Get("If-None-Match") == etag {
+				buf.status = http.StatusNotModified
+				buf.body.Reset()
+			}
+		}
+		if !c.Writer.compressing() && buf.status != http.StatusNotModified && h.Get("Content-Length") == "" {
+			h.Set("Content-Length", strconv.Itoa(buf.body.Len()))
+		}
+		c.Writer.flushBuffer()
+	}
+}
+
+// HTMLTransformer rewrites a buffered HTML response body before it is sent.
+type HTMLTransformer func(c *Context, html []byte) []byte
+
+// TransformHTML returns a middleware applying fns to the HTML responses of the handlers after it, in addition to the engine's HTMLTransformers.
+// Only responses held by BufferResponse are transformed, so it must be used after that middleware.
+func TransformHTML(fns ...HTMLTransformer) HandlerFunc {
+	return func(c *Context) {
+		c.htmlTransformers = append(c.htmlTransformers, fns...)
+	}
+}
+
+// InjectBeforeBodyEnd returns a transformer inserting snippet, e.g. an analytics script, just before </body>.
+func InjectBeforeBodyEnd(snippet string) HTMLTransformer {
+	return func(c *Context, html []byte) []byte {
+		i := bytes.LastIndex(html, []byte("</body>"))
+		if i < 0 {
+			return html
+		}
+		out := make([]byte, 0, len(html)+len(snippet))
+		out = append(out, html[:i]...)
+		out = append(out, snippet...)
+		return append(out, html[i:]...)
+	}
+}
+
+func isHTML(h http.Header, body []byte) bool {
+	ct := h.Get("Content-Type")
+	if ct == "" {
+		ct = http.DetectContentType(body)
+	}
+	return strings.HasPrefix(ct, "text/html")
+}
+
+// flushBuffer sends the buffered status and body and turns buffering off.
+func (w *ResponseWriter) flushBuffer() {
+	buf := w.buf
+	if buf == nil {
+		return
+	}
+	w.buf = nil
+	if buf.status != 0 {
+		w.WriteHeader(buf.status)
+	}
+	if buf.body.Len() > 0 {
+		w.write(buf.body.Bytes())
+	} else if buf.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// discardBuffer drops a buffered response so an error response can replace it.
+func (w *ResponseWriter) discardBuffer() {
+	if w.buf != nil {
+		w.buf.status = 0
+		w.buf.body.Reset()
+	}
+}
@@ -0,0 +1,68 @@
+package goweb
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CachePolicy decides the Cache-Control header of static files.
+type CachePolicy struct {
+	MaxAge time.Duration
+	// Fingerprinted matches file names carrying a content hash, e.g. app.3f9a2c.js, which are cached for a year and marked immutable.
+	Fingerprinted *regexp.Regexp
+	// HTMLNoCache makes browsers revalidate HTML files on every use, so new deployments are picked up.
+	HTMLNoCache bool
+}
+
+// DefaultCachePolicy caches assets for an hour, fingerprinted assets forever and always revalidates HTML.
+var DefaultCachePolicy = &CachePolicy{
+	MaxAge:        time.Hour,
+	Fingerprinted: regexp.MustCompile(`[.-][0-9a-f]{8,}\.[a-z0-9]+$`),
+	HTMLNoCache:   true,
+}
+
+func (p *CachePolicy) cacheControl(name string) string {
+	base := path.Base(name)
+	switch {
+	case p.Fingerprinted != nil && p.Fingerprinted.MatchString(base):
+		return "public, max-age=31536000, immutable"
+	case p.HTMLNoCache && (strings.HasSuffix(base, ".html") || strings.HasSuffix(base, ".htm")):
+		return "no-cache"
+	case p.MaxAge > 0:
+		return "public, max-age=" + strconv.Itoa(int(p.MaxAge/time.Second))
+	}
+	return "no-cache"
+}
+
+// ServeFile serves name from fs with Range, Last-Modified and ETag support, setting Cache-Control from policy when it is not nil.
+func (c *Context) ServeFile(fs http.FileSystem, name string, policy *CachePolicy) error {
+	f, err := fs.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", name)
+	}
+	h := c.Writer.Header()
+	if policy != nil {
+		h.Set("Cache-Control", policy.cacheControl(name))
+	}
+	if h.Get("ETag") == "" {
+		h.Set("ETag", fmt.Sprintf(`W/"%x-%x"`, info.ModTime().UnixNano(), info.Size()))
+	}
+	if c.Request.Header.Get("Range") != "" {
+		c.DisableCompression()
+	}
+	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), f)
+	return nil
+}
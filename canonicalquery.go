@@ -0,0 +1,63 @@
+package goweb
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// TrackingParams are query parameters added by marketing and analytics tools that never change what a page shows.
+var TrackingParams = []string{"utm_*", "gclid", "fbclid", "msclkid", "mc_cid", "mc_eid", "_ga", "yclid"}
+
+// QueryCanonicalizer turns a query string into a canonical form, with parameters filtered and sorted by name and value,
+// so equivalent URLs produce the same cache key.
+type QueryCanonicalizer struct {
+	// Allow lists the parameters kept, all parameters not dropped are kept when it is empty.
+	Allow []string
+	// Drop lists parameters removed, a trailing * matches by prefix. It defaults to TrackingParams.
+	Drop []string
+}
+
+func (qc QueryCanonicalizer) Canonicalize(rawQuery string) string {
+	values, _ := url.ParseQuery(rawQuery)
+	drop := qc.Drop
+	if drop == nil {
+		drop = TrackingParams
+	}
+	var names []string
+	for name := range values {
+		if len(qc.Allow) > 0 && !containsString(qc.Allow, name) || matchParam(drop, name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		vals := append([]string(nil), values[name]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(url.QueryEscape(name))
+			b.WriteByte('=')
+			b.WriteString(url.QueryEscape(v))
+		}
+	}
+	return b.String()
+}
+
+func matchParam(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if strings.HasSuffix(p, "*") && strings.HasPrefix(name, p[:len(p)-1]) || p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CanonicalQuery returns the canonical form of the request query string with tracking parameters dropped.
+func (c *Context) CanonicalQuery() string {
+	return QueryCanonicalizer{}.Canonicalize(c.Request.URL.RawQuery)
+}
@@ -20,6 +20,28 @@ func (hr HandlerResult) Write(w http.ResponseWriter) {
 	w.Write(json)
 }
 
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+func (p Problem) Write(w http.ResponseWriter) {
+	if p.Status == 0 {
+		p.Status = http.StatusInternalServerError
+	}
+	json, err := json.Marshal(p)
+	if err != nil {
+		panic(err)
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	w.Write(json)
+}
+
 func SanitizeHtml(html string) string {
 	return bluemondayPolicy.Sanitize(html)
 }
@@ -0,0 +1,167 @@
+package goweb
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Duration is a time.Duration read from strings such as "30s" in config files.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file" toml:"cert_file" env:"GOWEB_TLS_CERT_FILE"`
+	KeyFile  string `yaml:"key_file" toml:"key_file" env:"GOWEB_TLS_KEY_FILE"`
+}
+
+// Config holds the deployment settings of an engine, see LoadConfig.
+type Config struct {
+	Addr            string    `yaml:"addr" toml:"addr" env:"GOWEB_ADDR"`
+	TLS             TLSConfig `yaml:"tls" toml:"tls"`
+	ReadTimeout     Duration  `yaml:"read_timeout" toml:"read_timeout" env:"GOWEB_READ_TIMEOUT"`
+	WriteTimeout    Duration  `yaml:"write_timeout" toml:"write_timeout" env:"GOWEB_WRITE_TIMEOUT"`
+	IdleTimeout     Duration  `yaml:"idle_timeout" toml:"idle_timeout" env:"GOWEB_IDLE_TIMEOUT"`
+	ShutdownTimeout Duration  `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"GOWEB_SHUTDOWN_TIMEOUT"`
+	QueueTimeout    Duration  `yaml:"queue_timeout" toml:"queue_timeout" env:"GOWEB_QUEUE_TIMEOUT"`
+	Concurrency     int       `yaml:"concurrency" toml:"concurrency" env:"GOWEB_CONCURRENCY"`
+	TrustedProxies  []string  `yaml:"trusted_proxies" toml:"trusted_proxies" env:"GOWEB_TRUSTED_PROXIES"`
+	// The settings below can be changed without restarting, see Engine.Reload.
+	Maintenance           bool     `yaml:"maintenance" toml:"maintenance" env:"GOWEB_MAINTENANCE"`
+	MaintenanceAllowedIPs []string `yaml:"maintenance_allowed_ips" toml:"maintenance_allowed_ips" env:"GOWEB_MAINTENANCE_ALLOWED_IPS"`
+	BlockedIPs            []string `yaml:"blocked_ips" toml:"blocked_ips" env:"GOWEB_BLOCKED_IPS"`
+	path                  string
+}
+
+// LoadConfig reads a YAML (.yaml, .yml) or TOML (.toml) config file, then applies overrides from GOWEB_* environment variables.
+// An empty path loads the configuration from the environment only.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{path: path}
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(b, cfg)
+		case ".toml":
+			err = toml.Unmarshal(b, cfg)
+		default:
+			err = errors.New("unsupported config file format " + filepath.Ext(path))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := applyEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		fv := v.Field(i)
+		if t.Field(i).PkgPath != "" {
+			continue
+		}
+		if fv.Kind() == reflect.Struct {
+			if err := applyEnv(fv); err != nil {
+				return err
+			}
+			continue
+		}
+		name := t.Field(i).Tag.Get("env")
+		s, ok := os.LookupEnv(name)
+		if name == "" || !ok {
+			continue
+		}
+		switch fv.Interface().(type) {
+		case string:
+			fv.SetString(s)
+		case bool:
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+			fv.SetBool(b)
+		case int:
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+			fv.SetInt(int64(n))
+		case Duration:
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+			fv.SetInt(int64(d))
+		case []string:
+			var items []string
+			for _, item := range strings.Split(s, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			fv.Set(reflect.ValueOf(items))
+		}
+	}
+	return nil
+}
+
+// ApplyConfig copies the engine level settings of cfg onto the engine, zero values leave the current setting untouched.
+// It must be called before the engine serves its first request and panics otherwise, as requests read these settings
+// without synchronization. Use Reload or ReloadConfig to change settings at runtime.
+func (engine *Engine) ApplyConfig(cfg *Config) {
+	if atomic.LoadInt32(&engine.serving) != 0 {
+		panic("goweb: ApplyConfig called while the engine is serving requests, use Reload instead")
+	}
+	engine.Config = cfg
+	engine.Reload(cfg)
+	if cfg.Concurrency > 0 {
+		engine.ConcurrenceNumSem = make(chan int, cfg.Concurrency)
+	}
+	if cfg.QueueTimeout > 0 {
+		engine.QueueTimeout = time.Duration(cfg.QueueTimeout)
+	}
+	if cfg.ShutdownTimeout > 0 {
+		engine.ShutdownTimeout = time.Duration(cfg.ShutdownTimeout)
+	}
+}
+
+// RunConfig applies cfg to the engine and runs it with the listen address, TLS files and timeouts from cfg.
+func (engine *Engine) RunConfig(cfg *Config) error {
+	engine.ApplyConfig(cfg)
+	srv := &http.Server{
+		Addr:         cfg.Addr,
+		ReadTimeout:  time.Duration(cfg.ReadTimeout),
+		WriteTimeout: time.Duration(cfg.WriteTimeout),
+		IdleTimeout:  time.Duration(cfg.IdleTimeout),
+	}
+	if cfg.TLS.CertFile != "" || cfg.TLS.KeyFile != "" {
+		return engine.RunServer(srv, cfg.TLS.CertFile, cfg.TLS.KeyFile)
+	}
+	return engine.RunServer(srv)
+}
@@ -0,0 +1,147 @@
+package goweb
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ConnInfo describes the connection a request arrived on.
+type ConnInfo struct {
+	LocalAddr  net.Addr
+	RemoteAddr string
+	// TLS is nil for plain text connections.
+	TLS *tls.ConnectionState
+	// Proto is the protocol of the request, e.g. HTTP/1.1 or HTTP/2.0.
+	Proto string
+	// Requests is the number of requests served on the connection so far, this one included, so a value above 1 means the connection
+	// was reused. It is 0 when the server was not started with Run or RunServer.
+	Requests int
+}
+
+// Conn returns information on the connection of the request.
+func (c *Context) Conn() ConnInfo {
+	info := ConnInfo{RemoteAddr: c.Request.RemoteAddr, TLS: c.Request.TLS, Proto: c.Request.Proto, Requests: c.connRequests}
+	info.LocalAddr, _ = c.Request.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return info
+}
+
+// ConnStats counts the connections of servers started with Run or RunServer. Open is New plus Active plus Idle.
+type ConnStats struct {
+	Open     int
+	New      int
+	Active   int
+	Idle     int
+	Accepted int64
+	Closed   int64
+	Hijacked int64
+	// Requests is the number of requests served on connections, the average per connection tells how well keep-alive works.
+	Requests int64
+}
+
+// OpenConn describes a connection currently open.
+type OpenConn struct {
+	RemoteAddr string
+	State      http.ConnState
+	Opened     time.Time
+	Requests   int
+}
+
+// connTracker follows the connections of servers started with RunServer through their ConnState hook.
+type connTracker struct {
+	mu    sync.Mutex
+	conns map[net.Conn]*trackedConn
+	stats ConnStats
+}
+
+type trackedConn struct {
+	state    http.ConnState
+	opened   time.Time
+	requests int
+}
+
+func (t *connTracker) connState(conn net.Conn, state http.ConnState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conns == nil {
+		t.conns = map[net.Conn]*trackedConn{}
+	}
+	tc := t.conns[conn]
+	if tc != nil {
+		t.count(tc.state, -1)
+	}
+	switch state {
+	case http.StateNew:
+		t.conns[conn] = &trackedConn{state: state, opened: time.Now()}
+		t.stats.Accepted++
+	case http.StateActive, http.StateIdle:
+		if tc == nil {
+			return
+		}
+		tc.state = state
+	case http.StateHijacked, http.StateClosed:
+		if tc == nil {
+			return
+		}
+		delete(t.conns, conn)
+		if state == http.StateHijacked {
+			t.stats.Hijacked++
+		} else {
+			t.stats.Closed++
+		}
+		return
+	}
+	t.count(state, 1)
+}
+
+func (t *connTracker) count(state http.ConnState, delta int) {
+	switch state {
+	case http.StateNew:
+		t.stats.New += delta
+	case http.StateActive:
+		t.stats.Active += delta
+	case http.StateIdle:
+		t.stats.Idle += delta
+	}
+	t.stats.Open += delta
+}
+
+// served counts a request on the connection of req and returns the number of requests served on it.
+func (t *connTracker) served(req *http.Request) int {
+	conn := requestConn(req)
+	if conn == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tc := t.conns[conn]
+	if tc == nil {
+		return 0
+	}
+	tc.requests++
+	t.stats.Requests++
+	return tc.requests
+}
+
+// ConnStats returns a snapshot of the connection gauges and counters, e.g. for an OverloadHandler to shed load differently
+// when most connections are idle keep-alives.
+func (engine *Engine) ConnStats() ConnStats {
+	engine.conns.mu.Lock()
+	defer engine.conns.mu.Unlock()
+	return engine.conns.stats
+}
+
+// OpenConns lists the open connections, oldest first.
+func (engine *Engine) OpenConns() []OpenConn {
+	engine.conns.mu.Lock()
+	conns := make([]OpenConn, 0, len(engine.conns.conns))
+	for conn, tc := range engine.conns.conns {
+		conns = append(conns, OpenConn{RemoteAddr: conn.RemoteAddr().String(), State: tc.state, Opened: tc.opened, Requests: tc.requests})
+	}
+	engine.conns.mu.Unlock()
+	sort.Slice(conns, func(i, j int) bool { return conns[i].Opened.Before(conns[j].Opened) })
+	return conns
+}
@@ -1,24 +1,60 @@
 package goweb
 
 import (
+	"bufio"
 	"compress/gzip"
+	"errors"
 	"fmt"
+	"log"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
 type Context struct {
-	Engine     *Engine
-	Request    *http.Request
-	Writer     *ResponseWriter
-	CT         time.Time
+	Engine  *Engine
+	Request *http.Request
+	Writer  *ResponseWriter
+	CT      time.Time
+	// Deprecated: Signal is never used by goweb, communicate between handlers with Emit and On instead.
+	// It is still made for every request, removing it will be a breaking change.
 	Signal     chan int
 	Data       map[string]interface{}
 	index      int
 	handlers   HandlersChain
+	route      *node
+	params     Params
 	StatusCode int
+	// FuncMap is nil until the first SetFunc of the request.
 	FuncMap    map[string]interface{}
 	Err        error
+	RequestID  string
+	TraceID    string
+	logger     *log.Logger
+	serializer ResultSerializer
+	timings    []serverTiming
+	timingsMu  sync.Mutex
+	recovery   RecoveryFunc
+
+	htmlTransformers []HTMLTransformer
+	tenant           *Tenant
+	userID           string
+	roles            []string
+	loginReported    bool
+	device           *Device
+	negotiated       string
+	listeners        map[string][]EventHandler
+	panicked         bool
+	audit            *auditRecord
+	form             *formState
+	spam             bool
+	connRequests     int
+	// ownData and ownFuncMap are the maps made for Data and FuncMap, kept with the pooled Context.
+	ownData    map[string]interface{}
+	ownFuncMap map[string]interface{}
+	paramsBuf  Params
 }
 type ResponseWriter struct {
 	http.ResponseWriter
@@ -26,13 +62,28 @@ type ResponseWriter struct {
 	ctx         *Context
 	Compress    bool
 	initialized bool
+	wroteHeader bool
+	buf         *responseBuffer
+	// wantGzip is set by the Gzip middleware, compression starts with the response unless DisableCompression is called first.
+	wantGzip      bool
+	gzipFilter    func(contentType string) bool
+	pendingStatus int
+	conn          net.Conn
+	stalled       bool
+	hijacked      bool
+	dump          *responseDump
 }
 
 func (w *ResponseWriter) EnsureInitialzed(compress bool) {
 	if !w.initialized {
+		// a body the handler already encoded, e.g. a precompressed .br or .gz file, is sent as it is
+		if w.ResponseWriter.Header().Get("Content-Encoding") != "" {
+			compress = false
+		}
 		w.Compress = compress
 		if compress {
 			w.ResponseWriter.Header().Set("Content-Encoding", "gzip")
+			w.ResponseWriter.Header().Del("Content-Length")
 			w.gz = gzip.NewWriter(w.ResponseWriter)
 
 		}
@@ -40,26 +91,162 @@ func (w *ResponseWriter) EnsureInitialzed(compress bool) {
 	}
 }
 func (w *ResponseWriter) Close() {
+	if w.dump != nil {
+		defer w.finishDump()
+	}
+	if w.hijacked {
+		return
+	}
+	if w.buf != nil {
+		w.flushBuffer()
+	}
+	if !w.wroteHeader && w.pendingStatus != 0 {
+		// the handler set a status but wrote no body, an empty gzip stream would be pointless
+		w.sendHeader(false)
+	}
 	if w.gz != nil {
 		w.gz.Close()
 	}
+	// covers the header and the data net/http still holds after the handlers returned
+	w.armWriteDeadline(0)
 }
 func (w *ResponseWriter) Header() http.Header {
 	return w.ResponseWriter.Header()
 }
 func (w *ResponseWriter) Write(b []byte) (int, error) {
-	w.EnsureInitialzed(false)
+	if w.buf != nil {
+		return w.buf.write(b)
+	}
+	return w.write(b)
+}
+func (w *ResponseWriter) write(b []byte) (int, error) {
+	if w.hijacked {
+		return 0, http.ErrHijacked
+	}
 	if w.ResponseWriter.Header().Get("Content-Type") == "" {
 		w.ResponseWriter.Header().Set("Content-Type", http.DetectContentType(b))
 	}
-	if w.gz != nil {
-		return w.gz.Write(b)
+	if !w.wroteHeader {
+		w.sendHeader(len(b) > 0)
+	}
+	if w.ctx.Request.Method == http.MethodHead {
+		return len(b), nil
 	}
-	return w.ResponseWriter.Write(b)
+	return w.writeBody(b)
 }
+
+// WriteHeader records the status code. While compression is pending the header is held back until the first body write,
+// so the decision to compress can take the status, the content type and the presence of a body into account.
 func (w *ResponseWriter) WriteHeader(statusCode int) {
-	w.ResponseWriter.WriteHeader(statusCode)
+	if w.hijacked {
+		return
+	}
 	w.ctx.StatusCode = statusCode
+	if w.buf != nil {
+		w.buf.status = statusCode
+		return
+	}
+	if w.wroteHeader {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	w.pendingStatus = statusCode
+	if !w.wantGzip || w.initialized {
+		w.sendHeader(false)
+	}
+}
+
+func (w *ResponseWriter) sendHeader(hasBody bool) {
+	status := w.pendingStatus
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.EnsureInitialzed(hasBody && w.wantGzip && w.ctx.Request.Method != http.MethodHead && bodyAllowedForStatus(status) && w.gzipAllowed())
+	w.wroteHeader = true
+	w.ctx.writeServerTiming()
+	w.ResponseWriter.WriteHeader(status)
+	w.ctx.StatusCode = status
+	if w.dump != nil {
+		w.dump.status = status
+	}
+}
+
+func (w *ResponseWriter) finishDump() {
+	dump := w.dump
+	w.dump = nil
+	dump.finish(w.ctx)
+}
+
+func (w *ResponseWriter) gzipAllowed() bool {
+	if w.ResponseWriter.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+	if w.gzipFilter == nil {
+		return true
+	}
+	return w.gzipFilter(w.ResponseWriter.Header().Get("Content-Type"))
+}
+
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+// compressing reports whether the body is or will be gzip encoded.
+func (w *ResponseWriter) compressing() bool {
+	return w.gz != nil || !w.initialized && w.wantGzip
+}
+
+// Written reports whether the response header has been sent, or a status or body has been set aside to be sent.
+func (w *ResponseWriter) Written() bool {
+	return w.wroteHeader || w.pendingStatus != 0 || w.buf != nil && (w.buf.status != 0 || w.buf.body.Len() > 0)
+}
+
+// Flush implements http.Flusher, sending what was written so far, e.g. for server-sent events or streamed RPCs. It ends the buffering
+// of BufferResponse, and a response flushed before its first write is sent uncompressed as the Gzip decision cannot wait any longer.
+func (w *ResponseWriter) Flush() {
+	if w.hijacked {
+		return
+	}
+	w.flushBuffer()
+	if !w.wroteHeader {
+		w.sendHeader(false)
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.armWriteDeadline(0)
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker for websockets and other protocols taking over the connection, the response counts as written afterwards.
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	if w.guardsWrites() {
+		// the write deadline is meant for this response, not for the protocol taking over
+		conn.SetWriteDeadline(time.Time{})
+	}
+	w.hijacked = true
+	w.wroteHeader = true
+	w.buf = nil
+	if w.ctx.StatusCode == 0 {
+		w.ctx.StatusCode = http.StatusSwitchingProtocols
+	}
+	return conn, rw, nil
 }
 func (c *Context) Next() {
 	c.index++
@@ -69,22 +256,154 @@ func (c *Context) Next() {
 	}
 }
 
+const abortIndex = 10000000000000
+
 func (c *Context) Abort() {
-	c.index = 10000000000000
+	c.index = abortIndex
+}
+
+func (c *Context) IsAborted() bool {
+	return c.index >= abortIndex
 }
 
 func (c *Context) Success(data interface{}) {
-	HandlerResult{Data: data}.Write(c.Writer)
+	c.resultSerializer().WriteResult(c, 0, data, nil)
 }
 func (c *Context) Failed(error string) {
-	HandlerResult{Error: &error}.Write(c.Writer)
+	if c.Engine.ProblemJSON {
+		c.problem(http.StatusBadRequest, error).Write(c.Writer)
+		return
+	}
+	c.resultSerializer().WriteResult(c, 0, nil, errors.New(error))
+}
+
+// AbortWithError stops the handler chain and writes err with the given status code.
+func (c *Context) AbortWithError(status int, err error) {
+	c.Abort()
+	c.Err = err
+	if c.Engine.ProblemJSON {
+		c.problem(status, err.Error()).Write(c.Writer)
+		return
+	}
+	c.resultSerializer().WriteResult(c, status, nil, err)
+}
+
+func (c *Context) problem(status int, detail string) Problem {
+	return Problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: detail, Instance: c.Request.URL.Path}
 }
 
 type ErrorPageFunc func(c *Context, status int, msg string)
 
+// ShowErrorPage renders the engine's ErrorPage, or a minimal HTML page if none is set.
 func (c *Context) ShowErrorPage(status int, msg string) {
+	if c.Engine.ErrorPage != nil {
+		c.Engine.ErrorPage(c, status, msg)
+		return
+	}
+	defaultErrorPageFunc(c, status, msg)
+}
+
+// Set stores a value for the rest of the request in Data.
+func (c *Context) Set(key string, value interface{}) {
+	if c.Data == nil {
+		c.Data = map[string]interface{}{}
+	}
+	c.Data[key] = value
+}
+
+// Get returns the value stored with Set, and whether there was one.
+func (c *Context) Get(key string) (interface{}, bool) {
+	value, ok := c.Data[key]
+	return value, ok
 }
 
 func (c *Context) String() string {
-	return fmt.Sprintf("method:%s path:%s remote_ip:%s", c.Request.Method, c.Request.URL.Path, c.Request.RemoteAddr)
+	return fmt.Sprintf("method:%s path:%s remote_ip:%s", c.Request.Method, c.Request.URL.Path, c.loggedAddr())
+}
+
+// Logger returns a logger scoped to the current request, every line it writes carries the request_id and trace_id of the request.
+func (c *Context) Logger() *log.Logger {
+	if c.logger == nil {
+		prefix := c.Engine.Logger.Prefix() + "request_id=" + c.RequestID + " "
+		if c.tenant != nil && c.tenant.ProjectID != "" {
+			prefix += "project_id=" + c.tenant.ProjectID + " "
+		}
+		if c.userID != "" && c.TrackingAllowed() {
+			prefix += "user_id=" + c.userID + " "
+		}
+		if c.TraceID != "" {
+			prefix += "trace_id=" + c.TraceID + " "
+		}
+		c.logger = log.New(c.Engine.Logger.Writer(), prefix, c.Engine.Logger.Flags())
+	}
+	return c.logger
+}
+
+// Param returns the value of a named path parameter, e.g. c.Param("id") for a route registered as /users/:id.
+func (c *Context) Param(name string) string {
+	return c.params.Get(name)
+}
+
+// Params returns all path parameters of the request in the order they appear in the route. The slice is reused by later requests,
+// copy it to keep it beyond the handler.
+func (c *Context) Params() Params {
+	return c.params
+}
+
+// RouteMeta returns the metadata value set with Route.Meta on the matched route, or nil.
+func (c *Context) RouteMeta(key string) interface{} {
+	if c.route == nil {
+		return nil
+	}
+	return c.route.meta[key]
+}
+
+// SetUserID records the authenticated user or account the request is made by, for auth middleware to call once it has identified the caller.
+// Log lines written through Logger from then on carry the user_id.
+func (c *Context) SetUserID(id string) {
+	c.userID = id
+	c.logger = nil
+	c.Emit(EventUserIdentified, id)
+}
+
+// UserID returns the id set with SetUserID, or "" for anonymous requests.
+func (c *Context) UserID() string {
+	return c.userID
+}
+
+// requestID returns the X-Request-ID of the request if it is a safe token, as it ends up in log lines and outgoing headers,
+// or a new id otherwise.
+func (engine *Engine) requestID(req *http.Request) string {
+	if id := req.Header.Get("X-Request-Id"); validRequestID(id) {
+		return id
+	}
+	return engine.NewRequestID()
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		switch b := id[i]; {
+		case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9', b == '-', b == '_', b == '.', b == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// traceID extracts the trace id from a W3C traceparent header.
+func traceID(req *http.Request) string {
+	// headers are looked up by their canonical name, which spares the lookup an allocation
+	tp := req.Header.Get("Traceparent")
+	if tp == "" {
+		return ""
+	}
+	parts := strings.Split(tp, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 {
+		return ""
+	}
+	return parts[1]
 }
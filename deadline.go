@@ -0,0 +1,36 @@
+package goweb
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Timeout is a middleware giving the handlers after it a request deadline of d, exposed through Context.Deadline and Context.RemainingTime.
+func Timeout(d time.Duration) HandlerFunc {
+	return func(c *Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// Deadline returns the time the request should be completed by, ok is false when no deadline is set.
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	return c.Request.Context().Deadline()
+}
+
+// RemainingTime returns the time left before the request deadline, or math.MaxInt64 if there is none.
+// Context deadlines follow the wall clock, so it is measured with time.Until rather than the engine's Clock.
+func (c *Context) RemainingTime() time.Duration {
+	deadline, ok := c.Deadline()
+	if !ok {
+		return time.Duration(math.MaxInt64)
+	}
+	remaining := time.Until(deadline)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
@@ -0,0 +1,154 @@
+package goweb
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+type DeviceClass string
+
+const (
+	DeviceDesktop DeviceClass = "desktop"
+	DeviceMobile  DeviceClass = "mobile"
+	DeviceTablet  DeviceClass = "tablet"
+	DeviceBot     DeviceClass = "bot"
+)
+
+// Device is the client device as guessed from the User-Agent header.
+type Device struct {
+	Class DeviceClass
+	// OS is the operating system family, e.g. "ios", "android", "windows", or "" if unknown.
+	OS string
+	// Browser is the browser family, e.g. "chrome", "firefox", "safari", or "" if unknown.
+	Browser string
+}
+
+// Device classifies the client of the request, the User-Agent is only parsed once per request and results are shared
+// across requests through an LRU cache of Engine.DeviceCacheSize entries. With Engine.DisableDeviceDetection every client is a desktop.
+func (c *Context) Device() Device {
+	if c.device == nil {
+		d := Device{Class: DeviceDesktop}
+		if !c.Engine.DisableDeviceDetection {
+			d = c.Engine.devices.parse(c.Request.UserAgent(), c.Engine.DeviceCacheSize)
+		}
+		c.device = &d
+	}
+	return *c.device
+}
+
+// ClientInfo is what is known about the client of a request, put together from the client IP and the User-Agent.
+type ClientInfo struct {
+	IP      string
+	Device  DeviceClass
+	OS      string
+	Browser string
+	Bot     bool
+}
+
+// ClientInfo returns the client IP and device of the request, reusing the parsing done by ClientIP and Device.
+func (c *Context) ClientInfo() ClientInfo {
+	d := c.Device()
+	return ClientInfo{IP: c.ClientIP(), Device: d.Class, OS: d.OS, Browser: d.Browser, Bot: d.Class == DeviceBot}
+}
+
+func (c *Context) IsMobile() bool {
+	return c.Device().Class == DeviceMobile
+}
+
+func (c *Context) IsTablet() bool {
+	return c.Device().Class == DeviceTablet
+}
+
+func (c *Context) IsBot() bool {
+	return c.Device().Class == DeviceBot
+}
+
+// ParseDevice classifies a User-Agent string. It is a cheap heuristic good enough to pick a layout, not a full user agent database.
+func ParseDevice(ua string) Device {
+	l := strings.ToLower(ua)
+	d := Device{Class: DeviceDesktop}
+	switch {
+	case strings.Contains(l, "iphone"), strings.Contains(l, "ipod"):
+		d.OS = "ios"
+	case strings.Contains(l, "ipad"):
+		d.OS = "ios"
+		d.Class = DeviceTablet
+	case strings.Contains(l, "android"):
+		d.OS = "android"
+	case strings.Contains(l, "windows"):
+		d.OS = "windows"
+	case strings.Contains(l, "mac os x"), strings.Contains(l, "macintosh"):
+		d.OS = "macos"
+	case strings.Contains(l, "linux"):
+		d.OS = "linux"
+	}
+	switch {
+	case strings.Contains(l, "edg/"), strings.Contains(l, "edge/"):
+		d.Browser = "edge"
+	case strings.Contains(l, "opr/"), strings.Contains(l, "opera"):
+		d.Browser = "opera"
+	case strings.Contains(l, "firefox/"), strings.Contains(l, "fxios/"):
+		d.Browser = "firefox"
+	case strings.Contains(l, "chrome/"), strings.Contains(l, "crios/"):
+		d.Browser = "chrome"
+	case strings.Contains(l, "safari/"):
+		d.Browser = "safari"
+	case strings.Contains(l, "msie "), strings.Contains(l, "trident/"):
+		d.Browser = "ie"
+	}
+	switch {
+	case l == "", strings.Contains(l, "bot"), strings.Contains(l, "crawler"), strings.Contains(l, "spider"), strings.Contains(l, "curl/"), strings.Contains(l, "wget/"):
+		d.Class = DeviceBot
+	case d.Class == DeviceTablet, strings.Contains(l, "tablet"), d.OS == "android" && !strings.Contains(l, "mobile"):
+		d.Class = DeviceTablet
+	case strings.Contains(l, "mobile"), d.OS == "ios":
+		d.Class = DeviceMobile
+	}
+	return d
+}
+
+// maxCachedUserAgent keeps oversized, usually forged, User-Agent strings out of the device cache.
+const maxCachedUserAgent = 512
+
+// deviceCache holds the most recently parsed User-Agent strings, so popular clients are only classified once.
+type deviceCache struct {
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type deviceCacheEntry struct {
+	ua     string
+	device Device
+}
+
+func (dc *deviceCache) parse(ua string, size int) Device {
+	if size <= 0 || len(ua) > maxCachedUserAgent {
+		return ParseDevice(ua)
+	}
+	dc.mu.Lock()
+	if e, ok := dc.items[ua]; ok {
+		dc.ll.MoveToFront(e)
+		d := e.Value.(*deviceCacheEntry).device
+		dc.mu.Unlock()
+		return d
+	}
+	dc.mu.Unlock()
+	d := ParseDevice(ua)
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if dc.items == nil {
+		dc.ll = list.New()
+		dc.items = map[string]*list.Element{}
+	}
+	if _, ok := dc.items[ua]; !ok {
+		dc.items[ua] = dc.ll.PushFront(&deviceCacheEntry{ua: ua, device: d})
+		for dc.ll.Len() > size {
+			oldest := dc.ll.Back()
+			dc.ll.Remove(oldest)
+			delete(dc.items, oldest.Value.(*deviceCacheEntry).ua)
+		}
+	}
+	return d
+}
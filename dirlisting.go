@@ -0,0 +1,89 @@
+package goweb
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+type DirEntry struct {
+	Name    string
+	URL     string
+	Size    int64
+	ModTime time.Time
+	IsDir   bool
+}
+
+type DirListing struct {
+	Path    string
+	Entries []DirEntry
+}
+
+// DefaultDirListingTemplate is used by ListDirectory when no template is given, it has access to the engine's template functions.
+const DefaultDirListingTemplate = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Index of {{.Path}}</title></head>
+<body>
+<h1>Index of {{.Path}}</h1>
+<table>
+<tr><th>Name</th><th>Size</th><th>Modified</th></tr>
+{{if ne .Path "/"}}<tr><td><a href="../">../</a></td><td></td><td></td></tr>{{end}}
+{{range .Entries}}<tr><td><a href="{{.URL}}">{{.Name}}{{if .IsDir}}/{{end}}</a></td><td>{{if not .IsDir}}{{format_file_size (printf "%d" .Size)}}{{end}}</td><td>{{formatTime .ModTime ""}}</td></tr>
+{{end}}</table>
+</body>
+</html>`
+
+// ListDirectory renders the listing of directory name in fs, using tmpl or DefaultDirListingTemplate if tmpl is empty.
+// Directories are listed first, each group sorted by name. Nothing is written when it returns an error from the template.
+func (c *Context) ListDirectory(fs http.FileSystem, name string, tmpl string) error {
+	f, err := fs.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	infos, err := f.Readdir(-1)
+	if err != nil {
+		return err
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].IsDir() != infos[j].IsDir() {
+			return infos[i].IsDir()
+		}
+		return infos[i].Name() < infos[j].Name()
+	})
+	urlPath := c.Request.URL.Path
+	if !strings.HasSuffix(urlPath, "/") {
+		urlPath += "/"
+	}
+	listing := DirListing{Path: urlPath}
+	for _, info := range infos {
+		// Names are escaped so that ones containing #, ? or % still link to the entry.
+		entry := DirEntry{Name: info.Name(), URL: (&url.URL{Path: path.Join(urlPath, info.Name())}).EscapedPath(), Size: info.Size(), ModTime: info.ModTime(), IsDir: info.IsDir()}
+		if entry.IsDir {
+			entry.URL += "/"
+		}
+		listing.Entries = append(listing.Entries, entry)
+	}
+	if tmpl == "" {
+		tmpl = DefaultDirListingTemplate
+	}
+	t, err := template.New("dirlisting").Funcs(c.TemplateFuncs()).Parse(tmpl)
+	if err != nil {
+		return err
+	}
+	// rendered into a buffer first, so that a failing template leaves the response to the caller instead of sending half a page
+	buf := renderBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer putRenderBuffer(buf)
+	if err := t.Execute(buf, listing); err != nil {
+		return err
+	}
+	c.Writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err = c.Writer.Write(buf.Bytes())
+	return err
+}
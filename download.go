@@ -0,0 +1,99 @@
+package goweb
+
+import (
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type DownloadOptions struct {
+	// Filename is sent in the Content-Disposition header, it defaults to the base name of the file.
+	Filename string
+	// Inline asks the browser to display the file instead of saving it.
+	Inline bool
+	// RateLimit caps the transfer rate in bytes per second, 0 means unlimited.
+	RateLimit int64
+	// XSendfile hands the transfer off to the front web server with an X-Sendfile header carrying the file path.
+	XSendfile bool
+	// XAccelRedirect hands the transfer off to Nginx, it is the internal location the file is served from.
+	XAccelRedirect string
+}
+
+// Download serves the file at name with Range/If-Range support, or hands it off to the front web server when requested by opts.
+// Handed off files are not opened, they only need to be readable by the front web server.
+func (c *Context) Download(name string, opts DownloadOptions) error {
+	filename := opts.Filename
+	if filename == "" {
+		filename = filepath.Base(name)
+	}
+	disposition := "attachment"
+	if opts.Inline {
+		disposition = "inline"
+	}
+	disposition = mime.FormatMediaType(disposition, map[string]string{"filename": filename})
+	if opts.XAccelRedirect != "" {
+		c.Writer.Header().Set("Content-Disposition", disposition)
+		c.Writer.Header().Set("X-Accel-Redirect", opts.XAccelRedirect)
+		c.Writer.WriteHeader(http.StatusOK)
+		return nil
+	}
+	if opts.XSendfile {
+		abs, err := filepath.Abs(name)
+		if err != nil {
+			return err
+		}
+		c.Writer.Header().Set("Content-Disposition", disposition)
+		c.Writer.Header().Set("X-Sendfile", abs)
+		c.Writer.WriteHeader(http.StatusOK)
+		return nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	c.Writer.Header().Set("Content-Disposition", disposition)
+	// byte ranges refer to the file itself, so the transfer must not be compressed
+	c.DisableCompression()
+	var w http.ResponseWriter = c.Writer
+	if opts.RateLimit > 0 {
+		w = &throttledWriter{ResponseWriter: c.Writer, rate: opts.RateLimit, start: time.Now()}
+	}
+	http.ServeContent(w, c.Request, filename, info.ModTime(), f)
+	return nil
+}
+
+type throttledWriter struct {
+	http.ResponseWriter
+	rate    int64
+	start   time.Time
+	written int64
+}
+
+func (w *throttledWriter) Write(b []byte) (int, error) {
+	n := 0
+	for len(b) > 0 {
+		chunk := b
+		if int64(len(chunk)) > w.rate {
+			chunk = chunk[:w.rate]
+		}
+		m, err := w.ResponseWriter.Write(chunk)
+		n += m
+		w.written += int64(m)
+		if err != nil {
+			return n, err
+		}
+		b = b[m:]
+		expected := time.Duration(float64(w.written) / float64(w.rate) * float64(time.Second))
+		if elapsed := time.Since(w.start); elapsed < expected {
+			time.Sleep(expected - elapsed)
+		}
+	}
+	return n, nil
+}
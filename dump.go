@@ -0,0 +1,177 @@
+package goweb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync/atomic"
+)
+
+// Dumper is a debugging middleware writing the full request and response of matching requests to the engine logger or Output.
+type Dumper struct {
+	// Match selects the requests to dump, all requests are dumped when it is nil.
+	Match func(c *Context) bool
+	// MaxBodySize caps the number of body bytes dumped for both the request and the response.
+	MaxBodySize int64
+	Output      io.Writer
+	// RedactHeaders lists the request and response headers whose values are not dumped, DefaultDumpRedactHeaders when nil.
+	RedactHeaders []string
+	enabled       int32
+}
+
+func NewDumper(match func(c *Context) bool, maxBodySize int64) *Dumper {
+	return &Dumper{Match: match, MaxBodySize: maxBodySize, enabled: 1}
+}
+
+// SetEnabled toggles dumping at runtime, it is safe to call while requests are being served.
+func (d *Dumper) SetEnabled(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&d.enabled, v)
+}
+
+func (d *Dumper) Enabled() bool {
+	return atomic.LoadInt32(&d.enabled) == 1
+}
+
+// DefaultDumpRedactHeaders are the headers whose values are left out of dumps unless Dumper.RedactHeaders says otherwise.
+var DefaultDumpRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// trackingHeaders identify the client, they are left out of the dumps of requests whose client opted out of tracking.
+var trackingHeaders = []string{"User-Agent", "X-Forwarded-For", "X-Real-Ip", "Forwarded", "Cf-Connecting-Ip", "True-Client-Ip"}
+
+// Handler returns the dumping middleware. The response is dumped once it is complete, as the client got it: after BufferResponse
+// and any middleware that ran before it, and uncompressed when Gzip compressed it.
+func (d *Dumper) Handler() HandlerFunc {
+	return func(c *Context) {
+		if !d.Enabled() || d.Match != nil && !d.Match(c) || c.Writer.dump != nil {
+			return
+		}
+		dump := &responseDump{d: d}
+		buf := &dump.out
+		fmt.Fprintf(buf, "---- request %s ----\n", c.RequestID)
+		req := *c.Request
+		req.Header = d.redact(c, c.Request.Header)
+		head, err := httputil.DumpRequest(&req, false)
+		if err != nil {
+			c.Engine.Logger.Println(err)
+			return
+		}
+		buf.Write(head)
+		if len(c.Request.PostForm) > 0 {
+			buf.WriteString(capString(c.Request.PostForm.Encode(), d.MaxBodySize))
+		} else if c.Request.Body != nil {
+			body, err := ioutil.ReadAll(io.LimitReader(c.Request.Body, d.MaxBodySize))
+			if err != nil {
+				c.Engine.Logger.Println(err)
+				return
+			}
+			c.Request.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+			buf.Write(body)
+		}
+		c.Writer.dump = dump
+	}
+}
+
+// redact returns a copy of h with the values of the redacted headers replaced, and of the headers identifying the client
+// when it opted out of tracking.
+func (d *Dumper) redact(c *Context, h http.Header) http.Header {
+	names := d.RedactHeaders
+	if names == nil {
+		names = DefaultDumpRedactHeaders
+	}
+	if !c.TrackingAllowed() {
+		names = append(append([]string{}, names...), trackingHeaders...)
+	}
+	h = h.Clone()
+	for _, name := range names {
+		if _, ok := h[http.CanonicalHeaderKey(name)]; ok {
+			h.Set(name, "[redacted]")
+		}
+	}
+	return h
+}
+
+// responseDump collects the response of a dumped request as ResponseWriter sends it.
+type responseDump struct {
+	d      *Dumper
+	out    bytes.Buffer
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseDump) write(b []byte) {
+	if remaining := r.d.MaxBodySize - int64(r.body.Len()); remaining > 0 {
+		if int64(len(b)) > remaining {
+			b = b[:remaining]
+		}
+		r.body.Write(b)
+	}
+}
+
+// finish writes the dump out, it is called by ResponseWriter.Close.
+func (r *responseDump) finish(c *Context) {
+	buf := &r.out
+	fmt.Fprintf(buf, "\n---- response %s ----\n", c.RequestID)
+	status := r.status
+	if status == 0 {
+		status = c.StatusCode
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+	fmt.Fprintf(buf, "%d %s\n", status, http.StatusText(status))
+	r.d.redact(c, c.Writer.Header()).Write(buf)
+	buf.WriteString("\n")
+	buf.Write(r.body.Bytes())
+	if r.d.Output != nil {
+		buf.WriteString("\n")
+		r.d.Output.Write(buf.Bytes())
+	} else {
+		c.Engine.Logger.Println(buf.String())
+	}
+}
+
+// DumpIfHeader matches requests carrying the header name, e.g. X-Debug-Dump.
+func DumpIfHeader(name string) func(c *Context) bool {
+	return func(c *Context) bool {
+		return c.Request.Header.Get(name) != ""
+	}
+}
+
+// DumpIfIP matches requests coming from one of ips.
+func DumpIfIP(ips ...string) func(c *Context) bool {
+	return func(c *Context) bool {
+		host, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+		if err != nil {
+			host = c.Request.RemoteAddr
+		}
+		for _, ip := range ips {
+			if ip == host {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// DumpIfPathPrefix matches requests whose path starts with prefix.
+func DumpIfPathPrefix(prefix string) func(c *Context) bool {
+	return func(c *Context) bool {
+		return strings.HasPrefix(c.Request.URL.Path, prefix)
+	}
+}
+
+func capString(s string, max int64) string {
+	if int64(len(s)) > max {
+		return s[:max]
+	}
+	return s
+}
@@ -0,0 +1,50 @@
+package goweb
+
+import "sync"
+
+// Events emitted by goweb itself.
+const (
+	// EventUserIdentified is emitted by Context.SetUserID, the payload is the user id.
+	EventUserIdentified = "user.identified"
+	// EventLoginLockout is emitted by LoginGuard when a key gets locked out, the payload is the LockoutEvent.
+	EventLoginLockout = "login.lockout"
+)
+
+// EventHandler receives an event emitted while handling c.
+type EventHandler func(c *Context, payload interface{})
+
+type eventBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]EventHandler
+}
+
+// Subscribe registers h for every emission of event, on any request. Handlers run synchronously in the emitting goroutine.
+func (engine *Engine) Subscribe(event string, h EventHandler) {
+	engine.events.mu.Lock()
+	defer engine.events.mu.Unlock()
+	if engine.events.handlers == nil {
+		engine.events.handlers = map[string][]EventHandler{}
+	}
+	engine.events.handlers[event] = append(engine.events.handlers[event], h)
+}
+
+// On registers h for emissions of event during the current request only, e.g. for a middleware to learn what a later handler did.
+func (c *Context) On(event string, h EventHandler) {
+	if c.listeners == nil {
+		c.listeners = map[string][]EventHandler{}
+	}
+	c.listeners[event] = append(c.listeners[event], h)
+}
+
+// Emit calls the handlers subscribed to event on the engine, then those registered on the request with On.
+func (c *Context) Emit(event string, payload interface{}) {
+	c.Engine.events.mu.RLock()
+	handlers := c.Engine.events.handlers[event]
+	c.Engine.events.mu.RUnlock()
+	for _, h := range handlers {
+		h(c, payload)
+	}
+	for _, h := range c.listeners[event] {
+		h(c, payload)
+	}
+}
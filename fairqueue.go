@@ -0,0 +1,148 @@
+package goweb
+
+import (
+	"sync"
+	"time"
+)
+
+// FairByClientIP is a FairQueueKey giving every client address its own queue.
+func FairByClientIP(c *Context) string {
+	return c.ClientIP()
+}
+
+// slot is a place taken in the concurrency limiter, it is a plain value so that taking one allocates nothing.
+type slot struct {
+	sem  chan int
+	fair *fairLimiter
+}
+
+func (s slot) release() {
+	if s.fair != nil {
+		s.fair.release()
+		return
+	}
+	<-s.sem
+}
+
+// acquireSlot waits up to QueueTimeout for a free slot, which is given back with its release method.
+func (engine *Engine) acquireSlot(c *Context) (slot, bool) {
+	if engine.FairQueueKey != nil {
+		engine.fairOnce.Do(func() {
+			engine.fair = newFairLimiter()
+		})
+		fair := engine.fair
+		// the limit is taken from ConcurrenceNumSem, which is fixed once the engine serves requests
+		if !fair.acquire(engine.FairQueueKey(c), cap(engine.ConcurrenceNumSem), engine.QueueTimeout) {
+			return slot{}, false
+		}
+		return slot{fair: fair}, true
+	}
+	sem := engine.ConcurrenceNumSem
+	select {
+	case sem <- 1:
+		return slot{sem: sem}, true
+	default:
+	}
+	timeout := time.NewTimer(engine.QueueTimeout)
+	defer timeout.Stop()
+	select {
+	case sem <- 1:
+		return slot{sem: sem}, true
+	case <-timeout.C:
+		return slot{}, false
+	}
+}
+
+// fairLimiter admits at most limit holders, handing freed slots to the waiting keys in turn.
+type fairLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	inUse  int
+	queues map[string][]chan struct{}
+	// ring lists the keys with waiters in the order they are served.
+	ring []string
+}
+
+func newFairLimiter() *fairLimiter {
+	return &fairLimiter{limit: 1, queues: map[string][]chan struct{}{}}
+}
+
+// acquire waits up to timeout for one of limit slots, a limit differing from the previous one takes effect right away.
+func (l *fairLimiter) acquire(key string, limit int, timeout time.Duration) bool {
+	if limit < 1 {
+		limit = 1
+	}
+	l.mu.Lock()
+	if limit != l.limit {
+		l.limit = limit
+		l.dispatch()
+	}
+	if l.inUse < l.limit && len(l.ring) == 0 {
+		l.inUse++
+		l.mu.Unlock()
+		return true
+	}
+	ready := make(chan struct{})
+	if len(l.queues[key]) == 0 {
+		l.ring = append(l.ring, key)
+	}
+	l.queues[key] = append(l.queues[key], ready)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-ready:
+		return true
+	case <-timer.C:
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	queue := l.queues[key]
+	for i, w := range queue {
+		if w == ready {
+			l.queues[key] = append(queue[:i:i], queue[i+1:]...)
+			if len(l.queues[key]) == 0 {
+				l.dropKey(key)
+			}
+			return false
+		}
+	}
+	// the slot was handed over just as the wait timed out
+	return true
+}
+
+func (l *fairLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inUse--
+	l.dispatch()
+}
+
+// dispatch hands the free slots to the waiting keys in turn, slots beyond a lowered limit are not handed out again.
+func (l *fairLimiter) dispatch() {
+	for l.inUse < l.limit && len(l.ring) > 0 {
+		key := l.ring[0]
+		queue := l.queues[key]
+		next := queue[0]
+		l.queues[key] = queue[1:]
+		l.ring = l.ring[1:]
+		if len(l.queues[key]) == 0 {
+			delete(l.queues, key)
+		} else {
+			l.ring = append(l.ring, key)
+		}
+		l.inUse++
+		close(next)
+	}
+}
+
+func (l *fairLimiter) dropKey(key string) {
+	delete(l.queues, key)
+	for i, k := range l.ring {
+		if k == key {
+			l.ring = append(l.ring[:i:i], l.ring[i+1:]...)
+			return
+		}
+	}
+}
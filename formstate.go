@@ -0,0 +1,113 @@
+package goweb
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+)
+
+type formState struct {
+	values url.Values
+	errors map[string]string
+}
+
+// WithErrors keeps the submitted form and its validation errors for the next RenderPage, where templates read them back with
+// the formValue, formError and hasFormError functions. form may be url.Values, a struct bound with BindForm, or nil for the request form.
+// errs may be FormErrors, ValidationErrors, or any other error which is reported under the empty field name.
+func (c *Context) WithErrors(form interface{}, errs error) *Context {
+	state := &formState{errors: map[string]string{}}
+	switch f := form.(type) {
+	case nil:
+		state.values = c.Request.Form
+	case url.Values:
+		state.values = f
+	default:
+		state.values = structValues(f)
+	}
+	switch e := errs.(type) {
+	case nil:
+	case FormErrors:
+		for field, err := range e {
+			state.errors[field] = err.Error()
+		}
+	case ValidationErrors:
+		for field, msg := range e {
+			state.errors[field] = msg
+		}
+	default:
+		state.errors[""] = e.Error()
+	}
+	c.form = state
+	return c
+}
+
+// ValidationErrors is an error listing a message per form field, for validation done outside BindForm.
+type ValidationErrors map[string]string
+
+func (ve ValidationErrors) Error() string {
+	fe := FormErrors{}
+	for field, msg := range ve {
+		fe[field] = fmt.Errorf("%s", msg)
+	}
+	return fe.Error()
+}
+
+func (c *Context) formValue(name string) string {
+	if c.form != nil {
+		return c.form.values.Get(name)
+	}
+	return c.Request.FormValue(name)
+}
+
+func (c *Context) formError(name string) string {
+	if c.form == nil {
+		return ""
+	}
+	return c.form.errors[name]
+}
+
+func (c *Context) hasFormError(name string) bool {
+	return c.formError(name) != ""
+}
+
+// structValues turns the fields of a struct bound with BindForm back into form values, flattening embedded structs as BindForm does.
+func structValues(form interface{}) url.Values {
+	values := url.Values{}
+	v := reflect.Indirect(reflect.ValueOf(form))
+	if v.Kind() != reflect.Struct {
+		return values
+	}
+	addStructValues(v, values)
+	return values
+}
+
+func addStructValues(v reflect.Value, values url.Values) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := sf.Tag.Get("form")
+		if sf.PkgPath != "" || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			addStructValues(v.Field(i), values)
+			continue
+		}
+		fv := reflect.Indirect(v.Field(i))
+		if !fv.IsValid() || fv.Type() == fileHeaderType.Elem() {
+			continue
+		}
+		if fv.Kind() == reflect.Slice {
+			for j := 0; j < fv.Len(); j++ {
+				if item := reflect.Indirect(fv.Index(j)); item.IsValid() && item.Type() != fileHeaderType.Elem() {
+					values.Add(name, fmt.Sprint(item.Interface()))
+				}
+			}
+			continue
+		}
+		values.Set(name, fmt.Sprint(fv.Interface()))
+	}
+}
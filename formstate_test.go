@@ -0,0 +1,37 @@
+package goweb
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type Address struct {
+	City string `form:"city"`
+	Zip  int    `form:"zip"`
+}
+
+type testSignup struct {
+	Address
+	Name string   `form:"name"`
+	Tags []string `form:"tag"`
+}
+
+// TestWithErrorsEmbeddedStruct checks that the fields BindForm binds through an embedded struct are put back into the form.
+func TestWithErrorsEmbeddedStruct(t *testing.T) {
+	submitted := url.Values{"name": {"ann"}, "city": {"Oslo"}, "zip": {"150"}, "tag": {"a", "b"}}
+	req := httptest.NewRequest("POST", "/signup", strings.NewReader(submitted.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c := &Context{Engine: Default(), Request: req}
+	var form testSignup
+	if err := c.BindForm(&form); err != nil {
+		t.Fatal(err)
+	}
+	c.WithErrors(&form, nil)
+	for name, want := range submitted {
+		if got := c.form.values[name]; strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package goweb
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CountryLookup resolves an IP address to an ISO 3166-1 alpha-2 country code, e.g. backed by a MaxMind database.
+type CountryLookup interface {
+	Country(ip string) (string, error)
+}
+
+// CountryLookupFunc adapts a function to CountryLookup.
+type CountryLookupFunc func(ip string) (string, error)
+
+func (f CountryLookupFunc) Country(ip string) (string, error) {
+	return f(ip)
+}
+
+// CountryPrivate is the country reported for loopback, private (RFC 1918, ULA), link-local and carrier-grade NAT addresses,
+// which are never looked up.
+const CountryPrivate = "private"
+
+type GeoDecision string
+
+const (
+	GeoAllow     GeoDecision = "allow"
+	GeoDeny      GeoDecision = "deny"
+	GeoChallenge GeoDecision = "challenge"
+)
+
+// GeoPolicy decides per country what happens to a request. Countries listed in none of the fields get Default,
+// addresses that cannot be resolved get Unknown and private addresses get Private, which defaults to GeoAllow.
+type GeoPolicy struct {
+	Lookup    CountryLookup
+	Allow     []string
+	Deny      []string
+	Challenge []string
+	Default   GeoDecision
+	Unknown   GeoDecision
+	Private   GeoDecision
+	// ChallengeHandler answers challenged requests, e.g. with a captcha page. They are denied when it is nil.
+	ChallengeHandler HandlerFunc
+}
+
+// GeoAccess returns a middleware applying policy to the requests of the route group it is added to.
+// The country and decision are available to later handlers as the "geo_country" and "geo_decision" values of the Context.
+func GeoAccess(policy GeoPolicy) HandlerFunc {
+	if policy.Default == "" {
+		policy.Default = GeoAllow
+	}
+	if policy.Unknown == "" {
+		policy.Unknown = policy.Default
+	}
+	if policy.Private == "" {
+		policy.Private = GeoAllow
+	}
+	return func(c *Context) {
+		country, decision := policy.decide(c)
+		c.Set("geo_country", country)
+		c.Set("geo_decision", decision)
+		switch decision {
+		case GeoAllow:
+			return
+		case GeoChallenge:
+			c.Logger().Printf("geo access challenged%s", loggedCountry(c, country))
+			if policy.ChallengeHandler != nil {
+				policy.ChallengeHandler(c)
+				c.Abort()
+				return
+			}
+		}
+		c.Logger().Printf("geo access denied%s", loggedCountry(c, country))
+		c.AbortWithError(http.StatusForbidden, errors.New("access from your location is not allowed"))
+	}
+}
+
+// loggedCountry is the country field of geo access log lines, left out for clients that opted out of tracking.
+func loggedCountry(c *Context, country string) string {
+	if !c.TrackingAllowed() {
+		return ""
+	}
+	return " country=" + country
+}
+
+func (p GeoPolicy) decide(c *Context) (string, GeoDecision) {
+	ip := c.ClientIP()
+	if isPrivateIP(ip) {
+		return CountryPrivate, p.Private
+	}
+	country, err := p.Lookup.Country(ip)
+	if err != nil || country == "" {
+		// lookup errors tend to quote the address looked up
+		if err != nil && c.TrackingAllowed() {
+			c.Logger().Println("geo lookup:", err)
+		}
+		return "", p.Unknown
+	}
+	country = strings.ToUpper(country)
+	switch {
+	case containsFold(p.Deny, country):
+		return country, GeoDeny
+	case containsFold(p.Challenge, country):
+		return country, GeoChallenge
+	case containsFold(p.Allow, country):
+		return country, GeoAllow
+	}
+	return country, p.Default
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+var privateNets = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "::1/128", "fc00::/7", "fe80::/10"} {
+		_, n, _ := net.ParseCIDR(cidr)
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+// isPrivateIP reports whether ip is an address no geolocation database knows, it is false for addresses that do not parse.
+func isPrivateIP(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range privateNets {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// CachedCountryLookup caches the results of lookup for ttl, and failed or empty lookups for negativeTTL, so an unknown address
+// does not hit the database or a rate limited API on every request. At most size addresses are kept, a size of 0 or less
+// disables the cache and returns lookup as it is.
+func CachedCountryLookup(lookup CountryLookup, ttl, negativeTTL time.Duration, size int) CountryLookup {
+	if size <= 0 {
+		return lookup
+	}
+	return &countryCache{lookup: lookup, ttl: ttl, negativeTTL: negativeTTL, size: size, entries: map[string]countryCacheEntry{}}
+}
+
+type countryCache struct {
+	lookup      CountryLookup
+	ttl         time.Duration
+	negativeTTL time.Duration
+	size        int
+
+	mu      sync.Mutex
+	entries map[string]countryCacheEntry
+}
+
+type countryCacheEntry struct {
+	country string
+	err     error
+	expires time.Time
+}
+
+func (cc *countryCache) Country(ip string) (string, error) {
+	now := time.Now()
+	cc.mu.Lock()
+	e, ok := cc.entries[ip]
+	cc.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.country, e.err
+	}
+	country, err := cc.lookup.Country(ip)
+	ttl := cc.ttl
+	if err != nil || country == "" {
+		ttl = cc.negativeTTL
+	}
+	if ttl <= 0 {
+		return country, err
+	}
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if len(cc.entries) >= cc.size {
+		for key, e := range cc.entries {
+			if !now.Before(e.expires) || len(cc.entries) >= cc.size {
+				delete(cc.entries, key)
+			}
+		}
+	}
+	cc.entries[ip] = countryCacheEntry{country: country, err: err, expires: now.Add(ttl)}
+	return country, err
+}
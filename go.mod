@@ -3,6 +3,7 @@ module github.com/swishcloud/goweb
 go 1.16
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/google/uuid v1.2.0
 	github.com/lestrrat/go-jwx v0.0.0-20210302221443-a9d01c1b7121
@@ -11,4 +12,5 @@ require (
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/swishcloud/gostudy v0.0.0-20210425093220-d43a5c8312ae
 	golang.org/x/oauth2 v0.0.0-20210514164344-f6687ab2804c
+	gopkg.in/yaml.v3 v3.0.1
 )
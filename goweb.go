@@ -1,25 +1,119 @@
 package goweb
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"html/template"
+	"io"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
 	"path"
-	"strconv"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/swishcloud/gostudy/logger"
 )
 
 type Engine struct {
 	RouterGroup
-	trees             []methodTree
+	trees     []methodTree
+	pathTrees map[string]*pathTree
+	patterns  []methodTree
+	// ConcurrenceNumSem limits the requests handled at once, it must not be replaced once the engine serves requests.
 	ConcurrenceNumSem chan int
 	WM                *WidgetManager
 	Logger            *log.Logger
+	Clock             Clock
+	NewRequestID      func() string
+	// ProblemJSON makes Failed and AbortWithError respond with RFC 7807 application/problem+json bodies.
+	ProblemJSON      bool
+	ResultSerializer ResultSerializer
+	URLSigningKey    []byte
+	// Keyring encrypts cookies set with SetEncryptedCookie, and signs URLs when URLSigningKey is not set.
+	Keyring *Keyring
+	// QueueTimeout is how long a request waits for a free slot in ConcurrenceNumSem before OverloadHandler is called.
+	QueueTimeout    time.Duration
+	OverloadHandler OverloadFunc
+	// FairQueueKey switches the concurrency limiter to fair queuing: waiting requests are admitted round robin across the keys it returns,
+	// e.g. FairByClientIP, so a burst from one client cannot starve the others. The limit follows cap(ConcurrenceNumSem).
+	FairQueueKey    func(c *Context) string
+	fairOnce        sync.Once
+	fair            *fairLimiter
+	overload        *overloadCounters
+	ShutdownTimeout time.Duration
+	shutdownHooks   []func(ctx context.Context)
+	shutdownWG      sync.WaitGroup
+	server          *server
+	serverMu        sync.Mutex
+	// Config is the configuration applied with ApplyConfig, if any. ReloadConfig does not change it, the reloaded settings
+	// are swapped in atomically with the other runtime settings.
+	Config     *Config
+	reloadable atomic.Value
+	blockMu    sync.Mutex
+	ErrorPage  ErrorPageFunc
+	// HTMLTransformers rewrite every HTML response buffered by BufferResponse.
+	HTMLTransformers     []HTMLTransformer
+	TemplateErrorHandler TemplateErrorFunc
+	// MaintenanceAllow lets matching requests through while maintenance mode is on, e.g. health checks.
+	MaintenanceAllow Matcher
+	OpenAPIInfo      OpenAPIInfo
+	// AutoHEAD serves HEAD requests with the GET route of the path when it has no HEAD route, the body is discarded.
+	AutoHEAD bool
+	// RedirectTrailingSlash redirects requests no route matches to the same path with the trailing slash added or removed,
+	// when a route matches that one, e.g. /users/ to /users.
+	RedirectTrailingSlash bool
+	// RedirectFixedPath redirects requests no route matches to the cleaned path spelled as the route matching it ignoring case,
+	// e.g. /USERS/../Users/1 to /users/1.
+	RedirectFixedPath bool
+	// AutoOPTIONS answers OPTIONS requests to paths without an OPTIONS route with 204 and the methods of the path in the Allow header.
+	// Route middleware does not run for these responses, CORS preflight headers are added with UseBeforeRouting.
+	AutoOPTIONS bool
+	// WriteStallTimeout, when set, is how long a client may take to accept a write of the response, plus the time to take the written
+	// bytes at MinWriteRate bytes per second. Stalled clients get their response aborted, freeing the slot in ConcurrenceNumSem.
+	// It only applies to servers started with Run or RunServer, and replaces the WriteTimeout of the http.Server.
+	WriteStallTimeout time.Duration
+	MinWriteRate      int
+	// DeviceCacheSize is the number of User-Agent strings whose Context.Device result is kept, 0 parses on every request.
+	DeviceCacheSize int
+	// DisableDeviceDetection skips User-Agent parsing, Context.Device then reports every client as a desktop.
+	DisableDeviceDetection bool
+	// HonorDoNotTrack and TrackingConsent keep the IP address and user id of clients opting out of tracking out of the logs,
+	// see Context.TrackingAllowed.
+	HonorDoNotTrack bool
+	TrackingConsent func(c *Context) bool
+	// Banner, BannerText or BannerJSON, has Run log the listen address, the global middleware and the route table on startup.
+	Banner string
+	// PanicBreaker, when set, takes routes that keep panicking out of service for a while.
+	PanicBreaker *PanicBreaker
+
+	beforeRouting HandlersChain
+	noRoute       HandlersChain
+	noMethod      HandlersChain
+	funcs         template.FuncMap
+	funcsMu       sync.RWMutex
+	devices       deviceCache
+	events        eventBus
+	conns         connTracker
+	contextPool   sync.Pool
+	// serving is set by the first request, start-up only settings such as those of ApplyConfig are fixed from then on.
+	serving int32
+}
+
+type Clock interface {
+	Now() time.Time
+}
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
 }
 
 func Default() *Engine {
@@ -28,6 +122,14 @@ func Default() *Engine {
 	engine.ConcurrenceNumSem = make(chan int, 5)
 	engine.WM = NewWidgetManager()
 	engine.Logger = logger.NewLogger(os.Stdout, "GOWEB")
+	engine.Clock = systemClock{}
+	engine.NewRequestID = func() string { return uuid.New().String() }
+	engine.QueueTimeout = time.Second
+	engine.OverloadHandler = defaultOverloadHandler
+	engine.overload = &overloadCounters{}
+	engine.ShutdownTimeout = 30 * time.Second
+	engine.TemplateErrorHandler = defaultTemplateErrorHandler
+	engine.DeviceCacheSize = 1000
 	return &engine
 }
 
@@ -35,89 +137,256 @@ type HandlerFunc func(ctx *Context)
 type HandlersChain []HandlerFunc
 
 func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	timeout := make(chan bool, 1)
-	go func() {
-		time.Sleep(1 * time.Second)
-		timeout <- true
-	}()
-	context := &Context{Engine: engine, Request: req, CT: time.Now(), Signal: make(chan int), Data: make(map[string]interface{}), FuncMap: map[string]interface{}{}}
-	context.Writer = &ResponseWriter{ResponseWriter: w, ctx: context}
+	if atomic.LoadInt32(&engine.serving) == 0 {
+		atomic.StoreInt32(&engine.serving, 1)
+	}
+	context := engine.acquireContext(w, req)
+	engine.serve(context)
+	engine.releaseContext(context)
+}
+
+// acquireContext takes a Context from the pool and resets it for req. The maps it made for Data and FuncMap are emptied and reused,
+// so serving a request allocates neither, the deprecated Signal channel is still made for every request as handlers may close it.
+func (engine *Engine) acquireContext(w http.ResponseWriter, req *http.Request) *Context {
+	context, _ := engine.contextPool.Get().(*Context)
+	if context == nil {
+		context = &Context{ownData: map[string]interface{}{}, Writer: &ResponseWriter{}}
+	}
+	data, funcMap, writer := context.ownData, context.ownFuncMap, context.Writer
+	for k := range data {
+		delete(data, k)
+	}
+	for k := range funcMap {
+		delete(funcMap, k)
+	}
+	*context = Context{Engine: engine, Request: req, CT: engine.Clock.Now(), Signal: make(chan int), Data: data, FuncMap: funcMap,
+		ownData: data, ownFuncMap: funcMap, paramsBuf: context.paramsBuf[:0], Writer: writer}
+	*writer = ResponseWriter{ResponseWriter: w, ctx: context}
+	return context
+}
+
+// releaseContext puts context back in the pool, unless the connection was hijacked as the handler may still use it.
+func (engine *Engine) releaseContext(context *Context) {
+	if context.Writer.hijacked {
+		return
+	}
+	context.Request, context.Writer.ResponseWriter = nil, nil
+	engine.contextPool.Put(context)
+}
+
+func (engine *Engine) serve(context *Context) {
+	req := context.Request
+	context.RequestID = engine.requestID(req)
+	context.TraceID = traceID(req)
+	context.connRequests = engine.conns.served(req)
 	context.index = -1
-	context.FuncMap["formatTime"] = func(t time.Time, layout string) (string, error) {
-		if layout == "" {
-			layout = "01/02/2006 15:04"
-		}
-		tom := 0
-		c, err := context.Request.Cookie("tom")
-		if err == nil {
-			tom, err = strconv.Atoi(c.Value)
-			if err != nil {
-				panic(err)
-			}
+	// a deadline left over from the previous request on the connection would fail interim responses such as 100 Continue
+	context.Writer.armWriteDeadline(0)
+	if engine.logging() {
+		engine.Logger.Println("Incoming request:", context.Request.URL.Path, "Remote IP:", context.loggedAddr())
+	}
+	if engine.rejectByRuntimeSettings(context) {
+		return
+	}
+	start := time.Now()
+	atomic.AddInt64(&engine.overload.waiting, 1)
+	slot, ok := engine.acquireSlot(context)
+	atomic.AddInt64(&engine.overload.waiting, -1)
+	if ok {
+		engine.overload.admit(time.Since(start))
+		safelyHandle(engine, context)
+		slot.release()
+		return
+	}
+	engine.Logger.Println(context.Request.URL.Path, "server overload")
+	if engine.OverloadHandler(context) {
+		atomic.AddInt64(&engine.overload.bypassed, 1)
+		safelyHandle(engine, context)
+	} else {
+		atomic.AddInt64(&engine.overload.rejected, 1)
+		context.Writer.Close()
+	}
+}
+
+// routeRequest runs the UseBeforeRouting middleware and looks up the route of the request, it returns false if the middleware aborted.
+func (engine *Engine) routeRequest(c *Context) bool {
+	for _, handler := range engine.beforeRouting {
+		handler(c)
+		if c.IsAborted() {
+			return false
 		}
-		t = t.Add(-time.Duration(int64(time.Minute) * int64(tom)))
-		return t.Format(layout), nil
 	}
+	c.index = -1
+	if route, params := engine.findRoute(c.Request, c.paramsBuf[:0]); route != nil {
+		c.route = route
+		c.handlers = route.handlers
+		c.params = params
+		c.paramsBuf = params
+	}
+	return true
+}
 
-	context.FuncMap["formatTimeString"] = func(t_str string, layout string) (string, error) {
-		if layout == "" {
-			layout = "01/02/2006 15:04"
+// findRoute looks up the route for a request. Paths are looked up in the tree of the request method, regular expressions
+// are only tried when no path matches, and matchers last.
+func (engine *Engine) findRoute(req *http.Request, buf Params) (*node, Params) {
+	n, params := engine.findMethodRoute(req.Method, req.URL.Path, buf)
+	if n == nil && req.Method == http.MethodHead && engine.AutoHEAD {
+		n, params = engine.findMethodRoute(http.MethodGet, req.URL.Path, buf)
+	}
+	if n != nil {
+		return n, params
+	}
+	for _, v := range engine.patterns {
+		if v.root.matcher != nil && v.root.matcher(req) {
+			return v.root, nil
 		}
-		tom := 0
-		c, err := context.Request.Cookie("tom")
-		if err == nil {
-			tom, err = strconv.Atoi(c.Value)
-			if err != nil {
-				panic(err)
+	}
+	return nil, nil
+}
+
+// findMethodRoute appends the path parameters to buf, which lets the pooled Context reuse their storage.
+func (engine *Engine) findMethodRoute(method, path string, buf Params) (*node, Params) {
+	if t := engine.pathTrees[method]; t != nil && strings.HasPrefix(path, "/") {
+		if n := t.lookup(path[1:]); n != nil {
+			if !n.hasParams {
+				return n, nil
 			}
+			params, _ := matchParams(buf, n.path, path)
+			return n, params
 		}
-		t, err := time.Parse(time.RFC3339Nano, t_str)
-		if err != nil {
-			panic(err)
+	}
+	for _, v := range engine.patterns {
+		if v.method != method || v.root.regexp == nil {
+			continue
+		}
+		if m := v.root.regexp.FindStringSubmatch(path); m != nil {
+			return v.root, regexpParams(v.root.regexp, m)
 		}
-		t = t.Add(-time.Duration(int64(time.Minute) * int64(tom)))
-		return t.Format(layout), nil
 	}
-	context.FuncMap["format_file_size"] = func(sizeStr string) (string, error) {
-		size, err := strconv.ParseFloat(sizeStr, 64)
-		if err != nil {
-			return "", err
-		}
-		if size > 1024*1024*1024 {
-			return strconv.FormatFloat(size/1024/1024/1024, 'f', 2, 64) + " gb", nil
-		} else if size > 1024*1024 {
-			return strconv.FormatFloat(size/1024/1024, 'f', 2, 64) + " mb", nil
-		} else if size > 1024 {
-			return strconv.FormatFloat(size/1024, 'f', 2, 64) + " kb", nil
-		} else {
-			return strconv.FormatFloat(size, 'f', 0, 64) + " bytes", nil
+	return nil, nil
+}
+
+// allowedMethods returns the methods having a route for the request path, sorted.
+func (engine *Engine) allowedMethods(req *http.Request) []string {
+	path := req.URL.Path
+	var allowed []string
+	for method, t := range engine.pathTrees {
+		if strings.HasPrefix(path, "/") && t.lookup(path[1:]) != nil {
+			allowed = append(allowed, method)
+		}
+	}
+	for _, v := range engine.patterns {
+		if v.method != "" && v.root.regexp != nil && v.root.regexp.MatchString(path) && !containsString(allowed, v.method) {
+			allowed = append(allowed, v.method)
 		}
 	}
+	if engine.AutoHEAD && containsString(allowed, http.MethodGet) && !containsString(allowed, http.MethodHead) {
+		allowed = append(allowed, http.MethodHead)
+	}
+	if engine.AutoOPTIONS && len(allowed) > 0 && !containsString(allowed, http.MethodOptions) {
+		allowed = append(allowed, http.MethodOptions)
+	}
+	sort.Strings(allowed)
+	return allowed
+}
 
-	path := context.Request.URL.Path
-	engine.Logger.Println("Incoming request:", path, "Remote IP:", context.Request.RemoteAddr)
-	select {
-	case engine.ConcurrenceNumSem <- 1:
-		var handlers HandlersChain
-		for _, v := range engine.trees {
-			if v.root.path == path || v.root.regexp != nil && v.root.regexp.MatchString(path) {
-				if v.method == context.Request.Method {
-					handlers = v.root.handlers
-					break
+// redirectPath answers a request no route matched with a redirect to the path of a route, as allowed by RedirectTrailingSlash and RedirectFixedPath.
+// GET and HEAD requests get a 301, other methods a 308 so the body is sent again.
+func (engine *Engine) redirectPath(c *Context) bool {
+	p := c.Request.URL.Path
+	if !(engine.RedirectTrailingSlash || engine.RedirectFixedPath) || !strings.HasPrefix(p, "/") {
+		return false
+	}
+	methods := []string{c.Request.Method}
+	if c.Request.Method == http.MethodHead && engine.AutoHEAD {
+		methods = append(methods, http.MethodGet)
+	}
+	var target string
+	if engine.RedirectTrailingSlash && p != "/" {
+		if alt := toggleTrailingSlash(p); engine.pathRouted(methods, alt) {
+			target = alt
+		}
+	}
+	if target == "" && engine.RedirectFixedPath {
+		cleaned := path.Clean(p)
+		if strings.HasSuffix(p, "/") && cleaned != "/" {
+			cleaned += "/"
+		}
+		candidates := []string{cleaned}
+		if engine.RedirectTrailingSlash && cleaned != "/" {
+			candidates = append(candidates, toggleTrailingSlash(cleaned))
+		}
+	search:
+		for _, candidate := range candidates {
+			for _, method := range methods {
+				if t := engine.pathTrees[method]; t != nil {
+					if fixed, ok := t.lookupFold(candidate[1:]); ok {
+						target = "/" + fixed
+						break search
+					}
 				}
 			}
 		}
-		context.handlers = handlers
-		safelyHandle(engine, context)
-		<-engine.ConcurrenceNumSem
-	case <-timeout:
-		engine.Logger.Println(path, "server overload")
-		_, err := context.Writer.Write([]byte("server overload"))
-		if err != nil {
-			engine.Logger.Println(err)
+	}
+	if target == "" || target == p {
+		return false
+	}
+	// a target starting with // would be taken for another host
+	target = "/" + strings.TrimLeft(target, "/")
+	u := *c.Request.URL
+	u.Path, u.RawPath = target, ""
+	status := http.StatusMovedPermanently
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		status = http.StatusPermanentRedirect
+	}
+	http.Redirect(c.Writer, c.Request, u.String(), status)
+	return true
+}
+
+func (engine *Engine) pathRouted(methods []string, p string) bool {
+	for _, method := range methods {
+		if t := engine.pathTrees[method]; t != nil && t.lookup(p[1:]) != nil {
+			return true
 		}
 	}
+	return false
+}
+
+func toggleTrailingSlash(p string) string {
+	if strings.HasSuffix(p, "/") {
+		return p[:len(p)-1]
+	}
+	return p + "/"
+}
+
+// NotFound sets the handlers of requests no route matches, they run after the engine middleware with Context.Err set and
+// choose the status themselves, e.g. with c.AbortWithError(http.StatusNotFound, c.Err). A bare 404 is sent if they write nothing.
+func (engine *Engine) NotFound(handlers ...HandlerFunc) {
+	engine.noRoute = handlers
+}
+
+// MethodNotAllowed sets the handlers of requests whose path has routes for other methods only, the Allow header is already set
+// when they run. Like NotFound handlers they choose the status, a bare 405 is sent if they write nothing.
+func (engine *Engine) MethodNotAllowed(handlers ...HandlerFunc) {
+	engine.noMethod = handlers
+}
+
+func (engine *Engine) serveUnmatched(c *Context, status int, handlers HandlersChain) {
+	if len(handlers) > 0 {
+		c.handlers = engine.combineHandlers(handlers...)
+		c.Next()
+	}
+	if !c.Writer.Written() {
+		c.Writer.WriteHeader(status)
+	}
 }
+
+// logging reports whether the engine logger writes anywhere, the per request lines are skipped otherwise
+// so that their arguments are not allocated for nothing.
+func (engine *Engine) logging() bool {
+	return engine.Logger.Writer() != io.Discard
+}
+
 func safelyHandle(engine *Engine, c *Context) {
 	defer func() {
 		if err := recover(); err != nil {
@@ -133,15 +402,39 @@ func safelyHandle(engine *Engine, c *Context) {
 		if err := recover(); err != nil {
 			err_desc := fmt.Sprintf("%s", err)
 			c.Err = errors.New(err_desc)
+			c.panicked = true
 			engine.Logger.Println(err)
+			engine.PanicBreaker.recordPanic(c)
+			c.Writer.discardBuffer()
+			if c.recovery != nil {
+				c.recovery(c, err)
+			} else if !c.Writer.Written() {
+				c.Writer.WriteHeader(http.StatusInternalServerError)
+			}
 		}
 		engine.WM.HandlerWidget.Post_Process(c)
 	}()
 	engine.WM.HandlerWidget.Pre_Process(c)
+	// routing runs here so that the UseBeforeRouting middleware is recovered from and limited like the route handlers
+	if !engine.routeRequest(c) {
+		return
+	}
 	if c.handlers == nil {
-		c.Err = errors.New("page not found")
-		c.Writer.WriteHeader(404)
-	} else {
+		if engine.redirectPath(c) {
+			return
+		}
+		if allowed := engine.allowedMethods(c.Request); len(allowed) > 0 && c.Request.Method == http.MethodOptions && engine.AutoOPTIONS {
+			c.Writer.Header().Set("Allow", strings.Join(allowed, ", "))
+			c.Writer.WriteHeader(http.StatusNoContent)
+		} else if len(allowed) > 0 {
+			c.Err = errors.New("method not allowed")
+			c.Writer.Header().Set("Allow", strings.Join(allowed, ", "))
+			engine.serveUnmatched(c, http.StatusMethodNotAllowed, engine.noMethod)
+		} else {
+			c.Err = errors.New("page not found")
+			engine.serveUnmatched(c, http.StatusNotFound, engine.noRoute)
+		}
+	} else if engine.PanicBreaker.allow(c) && c.negotiate() {
 		err := c.Request.ParseForm()
 		if err != nil {
 			panic(err)
@@ -151,16 +444,62 @@ func safelyHandle(engine *Engine, c *Context) {
 }
 
 func (ctx *Context) RenderPage(data interface{}, filenames ...string) {
-	tmpl := template.New(path.Base(filenames[0])).Funcs(ctx.FuncMap)
+	filenames = ctx.themedFiles(filenames)
+	tmpl := template.New(path.Base(filenames[0])).Funcs(ctx.TemplateFuncs())
 	tmpl, err := tmpl.ParseFiles(filenames...)
 	if err != nil {
-		ctx.Engine.Logger.Println(err)
-		ctx.Writer.Write([]byte(fmt.Sprintf("%s", err)))
+		ctx.Engine.TemplateErrorHandler(ctx, err)
 		return
 	}
-	err = tmpl.Execute(ctx.Writer, data)
+	ctx.executePage(tmpl, data)
+}
+
+// RenderPageFS is RenderPage with the templates parsed from fsys, such as an embed.FS, the filenames may be fs.Glob patterns.
+// Tenant themes do not apply to them.
+func (ctx *Context) RenderPageFS(fsys fs.FS, data interface{}, filenames ...string) {
+	// the page is the first file, which for a pattern is its first match
+	name := path.Base(filenames[0])
+	if matches, err := fs.Glob(fsys, filenames[0]); err == nil && len(matches) > 0 {
+		name = path.Base(matches[0])
+	}
+	tmpl := template.New(name).Funcs(ctx.TemplateFuncs())
+	tmpl, err := tmpl.ParseFS(fsys, filenames...)
 	if err != nil {
-		ctx.Engine.Logger.Println(err)
+		ctx.Engine.TemplateErrorHandler(ctx, err)
 		return
 	}
+	ctx.executePage(tmpl, data)
+}
+
+// executePage renders tmpl into a buffer and writes it, handing execution errors to the TemplateErrorHandler.
+func (ctx *Context) executePage(tmpl *template.Template, data interface{}) {
+	buf := renderBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer putRenderBuffer(buf)
+	if err := tmpl.Execute(buf, data); err != nil {
+		ctx.Engine.TemplateErrorHandler(ctx, err)
+		return
+	}
+	ctx.Writer.Write(buf.Bytes())
+}
+
+// TemplateErrorFunc handles a template that failed to parse or execute in RenderPage, nothing of the page has been written when it is called.
+type TemplateErrorFunc func(c *Context, err error)
+
+func defaultTemplateErrorHandler(c *Context, err error) {
+	c.Engine.Logger.Println(err)
+	c.Err = err
+	if !c.Writer.Written() {
+		c.ShowErrorPage(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+	}
+}
+
+// renderBufferPool holds the buffers templates are rendered into, so a failing template never sends half a page.
+var renderBufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+func putRenderBuffer(buf *bytes.Buffer) {
+	// oversized buffers are left to the garbage collector rather than pinned in the pool
+	if buf.Cap() <= 1<<20 {
+		renderBufferPool.Put(buf)
+	}
 }
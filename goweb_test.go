@@ -0,0 +1,74 @@
+package goweb
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func benchmarkEngine() *Engine {
+	engine := Default()
+	engine.Logger = log.New(ioutil.Discard, "", 0)
+	engine.GET("/users/:id", func(c *Context) {
+		c.Writer.Write([]byte(c.Param("id")))
+	})
+	return engine
+}
+
+// BenchmarkServeHTTP measures the per request cost of a route that renders no template, which no longer builds the template functions.
+func BenchmarkServeHTTP(b *testing.B) {
+	engine := benchmarkEngine()
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+// BenchmarkTemplateFuncs measures what a rendering request pays to put the template functions together.
+func BenchmarkTemplateFuncs(b *testing.B) {
+	engine := benchmarkEngine()
+	c := &Context{Engine: engine, Request: httptest.NewRequest("GET", "/", nil)}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.TemplateFuncs()
+	}
+}
+
+// raceEnabled is set by race_test.go, the race detector makes sync.Pool drop items at random.
+var raceEnabled bool
+
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w discardResponseWriter) Header() http.Header         { return w.header }
+func (w discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w discardResponseWriter) WriteHeader(int)             {}
+
+// TestServeHTTPAllocs holds the routing fast path to its allocation budget: the only allocation left is the deprecated
+// Context.Signal channel, removing it is a breaking change. Request ids come from NewRequestID, which is stubbed out here.
+func TestServeHTTPAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not stable under the race detector")
+	}
+	engine := benchmarkEngine()
+	engine.NewRequestID = func() string { return "id" }
+	body := []byte("ok")
+	handler := func(c *Context) {
+		c.Writer.Write(body)
+	}
+	engine.GET("/health", handler)
+	engine.GET("/items/:id/*rest", handler)
+	w := discardResponseWriter{header: http.Header{}}
+	for _, path := range []string{"/health", "/items/42/a/b"} {
+		req := httptest.NewRequest("GET", path, nil)
+		if allocs := testing.AllocsPerRun(100, func() { engine.ServeHTTP(w, req) }); allocs > 1 {
+			t.Errorf("GET %s: %v allocations per request, want at most 1", path, allocs)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package goweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+)
+
+// GraphQLRequest is the operation carried by a GraphQL request, in the query string for GET and the JSON body for POST.
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type GraphQLOptions struct {
+	// Allow vets an operation before it reaches the handler, e.g. against a list of persisted queries. Returning an error rejects it with 403.
+	Allow func(c *Context, req GraphQLRequest) error
+	// Playground serves a GraphiQL page at the endpoint path followed by /playground.
+	Playground bool
+	// MaxBodySize caps POST bodies, it defaults to 1MB.
+	MaxBodySize int64
+}
+
+// GraphQL mounts a GraphQL handler, such as one from gqlgen or graphql-go, at path for GET and POST. The operation name is available to
+// later handlers and AccessLog as the "graphql_operation" value of the Context.
+func (group *RouterGroup) GraphQL(path string, handler http.Handler, opts GraphQLOptions) {
+	if opts.MaxBodySize == 0 {
+		opts.MaxBodySize = 1 << 20
+	}
+	serve := func(c *Context) {
+		req, err := readGraphQLRequest(c, opts.MaxBodySize)
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		operation := req.OperationName
+		if operation == "" {
+			operation = "anonymous"
+		}
+		c.Set("graphql_operation", operation)
+		if opts.Allow != nil {
+			if err := opts.Allow(c, req); err != nil {
+				c.Logger().Printf("graphql operation %s rejected: %v", operation, err)
+				c.AbortWithError(http.StatusForbidden, err)
+				return
+			}
+		}
+		handler.ServeHTTP(c.Writer, c.Request)
+	}
+	group.GET(path, serve)
+	group.POST(path, serve)
+	if opts.Playground {
+		group.GET(path+"/playground", func(c *Context) {
+			c.Writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+			if err := graphiQLTemplate.Execute(c.Writer, group.calculatePath(path)); err != nil {
+				c.Engine.Logger.Println(err)
+			}
+		})
+	}
+}
+
+// readGraphQLRequest decodes the operation, leaving the body readable for the GraphQL handler.
+func readGraphQLRequest(c *Context, maxBodySize int64) (GraphQLRequest, error) {
+	var req GraphQLRequest
+	if c.Request.Method == http.MethodGet {
+		q := c.Request.URL.Query()
+		req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				return req, errors.New("invalid graphql variables")
+			}
+		}
+	} else {
+		body, err := ioutil.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize))
+		if err != nil {
+			return req, err
+		}
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if err := json.Unmarshal(body, &req); err != nil {
+			return req, errors.New("invalid graphql request body")
+		}
+	}
+	if req.Query == "" {
+		return req, errors.New("missing graphql query")
+	}
+	return req, nil
+}
+
+var graphiQLTemplate = template.Must(template.New("graphiql").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>GraphiQL</title>
+<link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css">
+</head>
+<body style="margin:0">
+<div id="graphiql" style="height:100vh"></div>
+<script src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+<script src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+<script src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+<script>
+ReactDOM.createRoot(document.getElementById("graphiql")).render(
+  React.createElement(GraphiQL, {fetcher: GraphiQL.createFetcher({url: {{.}}})}));
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,23 @@
+package goweb
+
+import (
+	"net/http"
+	"strings"
+)
+
+// GRPCGateway mounts the JSON transcoding mux generated by grpc-gateway (a runtime.ServeMux, or any http.Handler) for all requests below
+// prefix, whatever their method, so proto defined APIs run through the group's middleware. The mux sees the full request path,
+// its routes are expected to include prefix as declared in the google.api.http annotations.
+// The request and trace ids are passed on to the gRPC service as x-request-id and traceparent metadata.
+// Server streaming RPCs flush every message through the http.Flusher of the ResponseWriter, which sends the header right away
+// and pushes the data through Gzip and BufferResponse instead of holding it until the RPC ends.
+func (group *RouterGroup) GRPCGateway(prefix string, mux http.Handler) *Route {
+	prefix = strings.TrimSuffix(group.calculatePath(prefix), "/")
+	return group.Match(MatchPathPrefix(prefix+"/"), func(c *Context) {
+		c.Request.Header.Set("Grpc-Metadata-X-Request-Id", c.RequestID)
+		if tp := c.Request.Header.Get("traceparent"); tp != "" {
+			c.Request.Header.Set("Grpc-Metadata-Traceparent", tp)
+		}
+		mux.ServeHTTP(c.Writer, c.Request)
+	})
+}
@@ -0,0 +1,46 @@
+package goweb
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+)
+
+type HoneypotOptions struct {
+	// Field is the name of the hidden input, pick something a form filling bot finds tempting such as "website".
+	Field string
+	// FlagOnly lets caught submissions through, only marking them for the handler to check with Context.IsSpam.
+	FlagOnly bool
+}
+
+// Honeypot returns a middleware rejecting form submissions that fill in the hidden field rendered by the honeypot template function.
+func Honeypot(opts HoneypotOptions) HandlerFunc {
+	if opts.Field == "" {
+		opts.Field = "website"
+	}
+	field := func() template.HTML {
+		return template.HTML(`<div style="position:absolute;left:-10000px" aria-hidden="true"><input type="text" name="` +
+			template.HTMLEscapeString(opts.Field) + `" tabindex="-1" autocomplete="off"></div>`)
+	}
+	return func(c *Context) {
+		c.SetFunc("honeypot", field)
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			return
+		}
+		if c.Request.PostFormValue(opts.Field) == "" {
+			return
+		}
+		c.spam = true
+		c.Logger().Println("honeypot field filled in, flagged as spam")
+		if !opts.FlagOnly {
+			c.AbortWithError(http.StatusBadRequest, errors.New("invalid form submission"))
+		}
+	}
+}
+
+// IsSpam reports whether the Honeypot middleware caught the request.
+func (c *Context) IsSpam() bool {
+	return c.spam
+}
@@ -0,0 +1,65 @@
+package goweb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type contextTransport struct {
+	c    *Context
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if ctx == context.Background() {
+		// requests made without a context of their own, e.g. with Get or http.NewRequest, end with the incoming request
+		ctx = t.ctx
+	}
+	req = req.Clone(ctx)
+	if req.Header.Get("X-Request-ID") == "" {
+		req.Header.Set("X-Request-ID", t.c.RequestID)
+	}
+	if tp := childTraceparent(t.c.Request.Header.Get("traceparent")); tp != "" && req.Header.Get("traceparent") == "" {
+		req.Header.Set("traceparent", tp)
+	}
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	t.c.ServerTiming("upstream", time.Since(start), "")
+	return resp, err
+}
+
+// childTraceparent returns the traceparent of an outgoing call made on behalf of a request carrying tp: the trace id and flags are kept
+// and the parent id is replaced by a new span id, as W3C Trace Context requires. It returns "" when tp is not a valid traceparent.
+func childTraceparent(tp string) string {
+	parts := strings.Split(tp, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return ""
+	}
+	var span [8]byte
+	if _, err := rand.Read(span[:]); err != nil {
+		return ""
+	}
+	return "00-" + parts[1] + "-" + hex.EncodeToString(span[:]) + "-" + parts[3]
+}
+
+// HTTPClient returns a client for calls to upstream services made while handling the request.
+// It forwards the request id and trace headers, is limited to the time left before the request deadline
+// and adds the time spent waiting on upstream responses to the "upstream" Server-Timing metric.
+// Calls made without a context of their own are canceled with the request, e.g. when the client goes away.
+func (c *Context) HTTPClient() *http.Client {
+	client := &http.Client{Transport: &contextTransport{c: c, ctx: c.Request.Context(), base: http.DefaultTransport}}
+	if _, ok := c.Deadline(); ok {
+		// a zero Timeout means no limit, so an exhausted budget still gets the smallest one
+		client.Timeout = c.RemainingTime()
+		if client.Timeout <= 0 {
+			client.Timeout = time.Nanosecond
+		}
+	}
+	return client
+}
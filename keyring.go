@@ -0,0 +1,154 @@
+package goweb
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+)
+
+const keyIDSize = 4
+
+var errUndecryptable = errors.New("value cannot be decrypted")
+
+// Keyring encrypts and authenticates values with AES-GCM. The first key seals new values, the others are only used to open
+// values sealed before a rotation, so a new key is put first and an old one removed once nothing sealed with it is in use.
+type Keyring struct {
+	keys []keyringKey
+}
+
+type keyringKey struct {
+	id     []byte
+	aead   cipher.AEAD
+	macKey []byte
+}
+
+// NewKeyring returns a Keyring for keys, each 16, 24 or 32 bytes long, the first being the current key.
+func NewKeyring(keys ...[]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("keyring needs at least one key")
+	}
+	kr := &Keyring{}
+	for _, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(key)
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte("goweb mac key"))
+		kr.keys = append(kr.keys, keyringKey{id: sum[:keyIDSize], aead: aead, macKey: mac.Sum(nil)})
+	}
+	return kr, nil
+}
+
+// Seal encrypts plaintext with the current key. associatedData is authenticated but not encrypted, pass what the value is for,
+// e.g. a cookie name, so a value sealed for one purpose is rejected for another.
+func (kr *Keyring) Seal(plaintext, associatedData []byte) ([]byte, error) {
+	key := kr.keys[0]
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append(append([]byte{}, key.id...), nonce...)
+	return key.aead.Seal(out, nonce, plaintext, associatedData), nil
+}
+
+// Open decrypts a value produced by Seal with any key of the keyring.
+func (kr *Keyring) Open(sealed, associatedData []byte) ([]byte, error) {
+	if len(sealed) < keyIDSize {
+		return nil, errUndecryptable
+	}
+	for _, key := range kr.keys {
+		if !hmac.Equal(sealed[:keyIDSize], key.id) {
+			continue
+		}
+		rest := sealed[keyIDSize:]
+		if len(rest) < key.aead.NonceSize() {
+			return nil, errUndecryptable
+		}
+		plaintext, err := key.aead.Open(nil, rest[:key.aead.NonceSize()], rest[key.aead.NonceSize():], associatedData)
+		if err != nil {
+			return nil, errUndecryptable
+		}
+		return plaintext, nil
+	}
+	return nil, errUndecryptable
+}
+
+// EncryptString is Seal for strings, the result is URL and cookie safe base64.
+func (kr *Keyring) EncryptString(plaintext, purpose string) (string, error) {
+	sealed, err := kr.Seal([]byte(plaintext), []byte(purpose))
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+func (kr *Keyring) DecryptString(encrypted, purpose string) (string, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", errUndecryptable
+	}
+	plaintext, err := kr.Open(sealed, []byte(purpose))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Sign returns a MAC of message made with the current key, for values that need authenticating but not hiding.
+func (kr *Keyring) Sign(message []byte) []byte {
+	return kr.keys[0].mac(message)
+}
+
+// Verify checks a MAC produced by Sign with any key of the keyring.
+func (kr *Keyring) Verify(message, signature []byte) bool {
+	for _, key := range kr.keys {
+		if hmac.Equal(signature, key.mac(message)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (k keyringKey) mac(message []byte) []byte {
+	mac := hmac.New(sha256.New, k.macKey)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
+
+// SetEncryptedCookie sets cookie with its value encrypted by the engine Keyring, bound to the cookie name.
+func (c *Context) SetEncryptedCookie(cookie *http.Cookie) error {
+	if c.Engine.Keyring == nil {
+		return errors.New("engine has no Keyring")
+	}
+	value, err := c.Engine.Keyring.EncryptString(cookie.Value, "cookie:"+cookie.Name)
+	if err != nil {
+		return err
+	}
+	encrypted := *cookie
+	encrypted.Value = value
+	http.SetCookie(c.Writer, &encrypted)
+	return nil
+}
+
+// EncryptedCookie returns the decrypted value of a cookie set with SetEncryptedCookie.
+func (c *Context) EncryptedCookie(name string) (string, error) {
+	if c.Engine.Keyring == nil {
+		return "", errors.New("engine has no Keyring")
+	}
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return c.Engine.Keyring.DecryptString(cookie.Value, "cookie:"+name)
+}
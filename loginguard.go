@@ -0,0 +1,240 @@
+package goweb
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LoginGuard slows down credential guessing on login and similar endpoints. Failed attempts are counted per client IP and per
+// identifier (user name, email), and a key reaching MaxAttempts is locked out for a period doubling with every lockout.
+type LoginGuard struct {
+	// MaxAttempts is the number of failures allowed within Window before a key is locked out.
+	MaxAttempts int
+	Window      time.Duration
+	// BaseLockout is the length of the first lockout, each further lockout of the same key doubles it up to MaxLockout,
+	// a MaxLockout of 0 means no maximum.
+	BaseLockout time.Duration
+	MaxLockout  time.Duration
+	// MaxEntries caps the number of tracked keys, 100000 if 0. Once reached, keys without a running lockout or attempt in progress
+	// are dropped, and when none can be, attempts of new keys are refused with 429 until lockouts expire.
+	MaxEntries int
+	// Identifier extracts the account identifier of an attempt, attempts are only counted per IP when it is nil or returns "".
+	Identifier func(c *Context) string
+	// OnLockout is called when a key gets locked out, e.g. to raise an alert or to block the IP with Engine.BlockIP.
+	OnLockout func(c *Context, e LockoutEvent)
+
+	mu        sync.Mutex
+	entries   map[string]*loginAttempts
+	lastSweep time.Time
+}
+
+// LockoutEvent describes a lockout, Kind is "ip" or "identifier".
+type LockoutEvent struct {
+	Kind     string
+	Key      string
+	IP       string
+	Lockouts int
+	Until    time.Time
+}
+
+type loginAttempts struct {
+	failures    []time.Time
+	lockouts    int
+	lockedUntil time.Time
+	// pending counts the attempts admitted by Protect that have not completed yet.
+	pending int
+}
+
+// NewLoginGuard returns a LoginGuard allowing 5 failures per 15 minutes, identifying accounts by the given form field.
+func NewLoginGuard(identifierField string) *LoginGuard {
+	return &LoginGuard{
+		MaxAttempts: 5,
+		Window:      15 * time.Minute,
+		BaseLockout: time.Minute,
+		MaxLockout:  24 * time.Hour,
+		Identifier: func(c *Context) string {
+			return c.Request.PostFormValue(identifierField)
+		},
+	}
+}
+
+// Protect returns a middleware answering locked out clients with 429. A response with status 401 counts as a failed attempt,
+// handlers reporting failures otherwise, e.g. by re-rendering the login form, call Fail and Succeed themselves.
+// Attempts in progress count against MaxAttempts, so concurrent requests cannot get past the limit before their failures are recorded.
+func (g *LoginGuard) Protect() HandlerFunc {
+	return func(c *Context) {
+		now := c.Engine.Clock.Now()
+		until, reserved := g.reserve(c, now)
+		if until.After(now) {
+			c.Writer.Header().Set("Retry-After", strconv.Itoa(int(until.Sub(now)/time.Second)+1))
+			c.AbortWithError(http.StatusTooManyRequests, errors.New("too many failed attempts, try again later"))
+			return
+		}
+		defer g.release(reserved)
+		c.Next()
+		if !c.loginReported && c.StatusCode == http.StatusUnauthorized {
+			g.Fail(c)
+		}
+	}
+}
+
+// Fail records a failed attempt for the client IP and identifier of the request.
+func (g *LoginGuard) Fail(c *Context) {
+	c.loginReported = true
+	now := c.Engine.Clock.Now()
+	ip := c.ClientIP()
+	var events []LockoutEvent
+	g.mu.Lock()
+	for kind, key := range g.keys(c) {
+		if e, locked := g.fail(kind, key, now); locked {
+			e.IP = ip
+			events = append(events, e)
+		}
+	}
+	g.mu.Unlock()
+	for _, e := range events {
+		c.Logger().Printf("login lockout %s=%s until %s", e.Kind, e.Key, e.Until.Format(time.RFC3339))
+		if g.OnLockout != nil {
+			g.OnLockout(c, e)
+		}
+		c.Emit(EventLoginLockout, e)
+	}
+}
+
+// Succeed clears the failures of the identifier and client IP of the request.
+func (g *LoginGuard) Succeed(c *Context) {
+	c.loginReported = true
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for kind, key := range g.keys(c) {
+		delete(g.entries, kind+":"+key)
+	}
+}
+
+func (g *LoginGuard) keys(c *Context) map[string]string {
+	keys := map[string]string{"ip": c.ClientIP()}
+	if g.Identifier != nil {
+		if id := g.Identifier(c); id != "" {
+			keys["identifier"] = id
+		}
+	}
+	return keys
+}
+
+// reserve admits an attempt of the request unless one of its keys is locked out or has its remaining attempts taken by
+// attempts in progress, it returns the time to retry after in that case.
+func (g *LoginGuard) reserve(c *Context, now time.Time) (time.Time, []*loginAttempts) {
+	keys := g.keys(c)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var until time.Time
+	attempts := make([]*loginAttempts, 0, len(keys))
+	for kind, key := range keys {
+		a := g.entry(kind+":"+key, now)
+		if a == nil {
+			c.Logger().Println("login guard is tracking MaxEntries keys, refusing the attempt")
+			return now.Add(time.Second), nil
+		}
+		if a.lockedUntil.After(until) {
+			until = a.lockedUntil
+		}
+		if g.MaxAttempts > 0 && len(g.recentFailures(a, now))+a.pending >= g.MaxAttempts && !until.After(now) {
+			until = now.Add(time.Second)
+		}
+		attempts = append(attempts, a)
+	}
+	if until.After(now) {
+		return until, nil
+	}
+	for _, a := range attempts {
+		a.pending++
+	}
+	return until, attempts
+}
+
+func (g *LoginGuard) release(attempts []*loginAttempts) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, a := range attempts {
+		a.pending--
+	}
+}
+
+// entry returns the attempts of key, creating them if needed. The entries are swept once per Window and capped at MaxEntries,
+// entry returns nil when the table is full of running lockouts and attempts in progress, as evicting them would lift the lockouts.
+func (g *LoginGuard) entry(key string, now time.Time) *loginAttempts {
+	if a := g.entries[key]; a != nil {
+		return a
+	}
+	if g.entries == nil {
+		g.entries = map[string]*loginAttempts{}
+	}
+	if now.Sub(g.lastSweep) >= g.Window {
+		g.sweep(now, false)
+		g.lastSweep = now
+	}
+	max := g.MaxEntries
+	if max <= 0 {
+		max = 100000
+	}
+	if len(g.entries) >= max {
+		g.sweep(now, true)
+		if len(g.entries) >= max {
+			return nil
+		}
+	}
+	a := &loginAttempts{}
+	g.entries[key] = a
+	return a
+}
+
+func (g *LoginGuard) recentFailures(a *loginAttempts, now time.Time) []time.Time {
+	failures := a.failures[:0]
+	for _, t := range a.failures {
+		if now.Sub(t) < g.Window {
+			failures = append(failures, t)
+		}
+	}
+	a.failures = failures
+	return failures
+}
+
+func (g *LoginGuard) fail(kind, key string, now time.Time) (LockoutEvent, bool) {
+	a := g.entry(kind+":"+key, now)
+	if a == nil {
+		return LockoutEvent{}, false
+	}
+	a.failures = append(g.recentFailures(a, now), now)
+	if len(a.failures) < g.MaxAttempts {
+		return LockoutEvent{}, false
+	}
+	// doubling stops short of overflowing, a.lockouts keeps growing while the lockout stays at its longest
+	lockout := g.BaseLockout
+	for i := 0; i < a.lockouts && lockout <= maxDuration/2; i++ {
+		lockout *= 2
+	}
+	if g.MaxLockout > 0 && lockout > g.MaxLockout {
+		lockout = g.MaxLockout
+	}
+	a.lockouts++
+	a.lockedUntil = now.Add(lockout)
+	a.failures = nil
+	return LockoutEvent{Kind: kind, Key: key, Lockouts: a.lockouts, Until: a.lockedUntil}, true
+}
+
+const maxDuration = time.Duration(1<<63 - 1)
+
+// sweep forgets keys with neither an attempt in progress, a running lockout nor, unless force is set, recent failures.
+func (g *LoginGuard) sweep(now time.Time, force bool) {
+	for key, a := range g.entries {
+		if a.pending > 0 || a.lockedUntil.After(now) {
+			continue
+		}
+		if force || len(a.failures) == 0 || now.Sub(a.failures[len(a.failures)-1]) >= g.Window {
+			delete(g.entries, key)
+		}
+	}
+}
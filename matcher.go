@@ -0,0 +1,115 @@
+package goweb
+
+import (
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Matcher is a request predicate, matchers compose with And, Or and Not.
+type Matcher func(r *http.Request) bool
+
+func (m Matcher) And(others ...Matcher) Matcher {
+	return func(r *http.Request) bool {
+		if !m(r) {
+			return false
+		}
+		for _, o := range others {
+			if !o(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func (m Matcher) Or(others ...Matcher) Matcher {
+	return func(r *http.Request) bool {
+		if m(r) {
+			return true
+		}
+		for _, o := range others {
+			if o(r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func Not(m Matcher) Matcher {
+	return func(r *http.Request) bool {
+		return !m(r)
+	}
+}
+
+func MatchMethod(methods ...string) Matcher {
+	return func(r *http.Request) bool {
+		for _, method := range methods {
+			if r.Method == method {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func MatchPathPrefix(prefix string) Matcher {
+	return func(r *http.Request) bool {
+		return strings.HasPrefix(r.URL.Path, prefix)
+	}
+}
+
+func MatchPathRegexp(re *regexp.Regexp) Matcher {
+	return func(r *http.Request) bool {
+		return re.MatchString(r.URL.Path)
+	}
+}
+
+// MatchHeader matches requests whose header name equals value, or carries the header at all when value is empty.
+func MatchHeader(name, value string) Matcher {
+	return func(r *http.Request) bool {
+		if value == "" {
+			return r.Header.Get(name) != ""
+		}
+		return r.Header.Get(name) == value
+	}
+}
+
+// MatchHost matches the request host, ignoring the port and case.
+func MatchHost(hosts ...string) Matcher {
+	return func(r *http.Request) bool {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		for _, h := range hosts {
+			if strings.EqualFold(host, h) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// When returns a middleware running handlers only for requests matched by m, e.g. When(MatchPathPrefix("/api"), Gzip).
+func When(m Matcher, handlers ...HandlerFunc) HandlerFunc {
+	return func(c *Context) {
+		if !m(c.Request) {
+			return
+		}
+		for _, handler := range handlers {
+			handler(c)
+			if c.IsAborted() {
+				return
+			}
+		}
+	}
+}
+
+// Match registers handlers for every request matched by m, whatever its method. Exact and regular expression routes take precedence.
+func (group *RouterGroup) Match(m Matcher, handlers ...HandlerFunc) *Route {
+	mustHaveHandlers(handlers, "matcher")
+	return group.addRoute("", &node{matcher: m, handlers: group.combineHandlers(handlers...)})
+}
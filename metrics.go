@@ -0,0 +1,36 @@
+package goweb
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+)
+
+// MetricsHandler serves the connection and concurrency limiter statistics in the Prometheus text format.
+func MetricsHandler(c *Context) {
+	cs := c.Engine.ConnStats()
+	ls := c.Engine.OverloadStats()
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# HELP goweb_connections Open connections by state.\n# TYPE goweb_connections gauge\n")
+	fmt.Fprintf(&b, "goweb_connections{state=\"new\"} %d\n", cs.New)
+	fmt.Fprintf(&b, "goweb_connections{state=\"active\"} %d\n", cs.Active)
+	fmt.Fprintf(&b, "goweb_connections{state=\"idle\"} %d\n", cs.Idle)
+	counters := []struct {
+		name, help string
+		value      int64
+	}{
+		{"goweb_connections_accepted_total", "Connections accepted.", cs.Accepted},
+		{"goweb_connections_closed_total", "Connections closed.", cs.Closed},
+		{"goweb_connections_hijacked_total", "Connections taken over by handlers, e.g. for websockets.", cs.Hijacked},
+		{"goweb_connection_requests_total", "Requests served on tracked connections.", cs.Requests},
+		{"goweb_requests_admitted_total", "Requests admitted by the concurrency limiter.", ls.Admitted},
+		{"goweb_requests_rejected_total", "Requests rejected by the overload handler.", ls.Rejected},
+		{"goweb_requests_bypassed_total", "Requests served past the concurrency limit by the overload handler.", ls.Bypassed},
+	}
+	for _, m := range counters {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", m.name, m.help, m.name, m.name, m.value)
+	}
+	fmt.Fprintf(&b, "# HELP goweb_requests_waiting Requests waiting for a slot.\n# TYPE goweb_requests_waiting gauge\ngoweb_requests_waiting %d\n", ls.Waiting)
+	fmt.Fprintf(&b, "# HELP goweb_queue_wait_seconds_total Time admitted requests waited for a slot.\n# TYPE goweb_queue_wait_seconds_total counter\ngoweb_queue_wait_seconds_total %g\n", ls.TotalWaitTime.Seconds())
+	c.writeBody(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", b.Bytes())
+}
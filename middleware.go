@@ -0,0 +1,128 @@
+package goweb
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestIDHeader echoes the request id in the X-Request-ID response header.
+func RequestIDHeader(c *Context) {
+	c.Writer.Header().Set("X-Request-ID", c.RequestID)
+}
+
+// AccessLog logs the method, path, status and duration of each request with the request-scoped logger. The line is written from a defer,
+// so requests whose handlers panic are logged too, with status 500 unless one was already sent, and marked panic=true.
+func AccessLog(c *Context) {
+	start := time.Now()
+	completed := false
+	defer func() {
+		status := c.StatusCode
+		if status == 0 && !completed {
+			status = http.StatusInternalServerError
+		} else if status == 0 {
+			status = http.StatusOK
+		}
+		line := fmt.Sprintf("%s %s %d %s", c.Request.Method, c.Request.URL.RequestURI(), status, time.Since(start))
+		if op, ok := c.Get("graphql_operation"); ok {
+			line += fmt.Sprintf(" graphql_operation=%s", op)
+		}
+		if !completed {
+			line += " panic=true"
+		}
+		c.Logger().Print(line)
+	}()
+	c.Next()
+	completed = true
+}
+
+// Gzip compresses the response when the client accepts gzip encoding, except for content types that are already compressed.
+func Gzip(c *Context) {
+	gzipWith(c, compressibleContentType)
+}
+
+// GzipFor is Gzip with the content types to compress selected by compressible.
+func GzipFor(compressible func(contentType string) bool) HandlerFunc {
+	return func(c *Context) {
+		gzipWith(c, compressible)
+	}
+}
+
+func gzipWith(c *Context, compressible func(contentType string) bool) {
+	c.Writer.Header().Add("Vary", "Accept-Encoding")
+	if acceptsEncoding(c.Request.Header.Get("Accept-Encoding"), "gzip") {
+		c.Writer.wantGzip = true
+		c.Writer.gzipFilter = compressible
+	}
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header allows coding, honoring q=0 exclusions and the * wildcard.
+func acceptsEncoding(acceptEncoding, coding string) bool {
+	q, wildcard := -1.0, -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		value := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					value = v
+				}
+			}
+		}
+		switch {
+		case name == coding, name == "x-"+coding:
+			q = value
+		case name == "*":
+			wildcard = value
+		}
+	}
+	if q >= 0 {
+		return q > 0
+	}
+	return wildcard > 0
+}
+
+func compressibleContentType(contentType string) bool {
+	switch {
+	case strings.HasPrefix(contentType, "image/svg"):
+		return true
+	case strings.HasPrefix(contentType, "image/"), strings.HasPrefix(contentType, "video/"), strings.HasPrefix(contentType, "audio/"):
+		return false
+	case strings.HasPrefix(contentType, "application/zip"), strings.HasPrefix(contentType, "application/gzip"), strings.HasPrefix(contentType, "application/x-gzip"):
+		return false
+	}
+	return true
+}
+
+// DisableCompression turns off the compression requested by the Gzip middleware, e.g. for server-sent events or already compressed files.
+// It has no effect once the response header is written.
+func (c *Context) DisableCompression() {
+	c.Writer.wantGzip = false
+}
+
+// NoCompression is a middleware opting the route or group out of the compression set up by Gzip.
+func NoCompression(c *Context) {
+	c.DisableCompression()
+}
+
+// SecurityHeaders sets conservative defaults for the common security related response headers.
+func SecurityHeaders(c *Context) {
+	h := c.Writer.Header()
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("X-Frame-Options", "SAMEORIGIN")
+	h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+}
+
+// APIDefaults is the recommended middleware stack for JSON API route groups.
+func APIDefaults() HandlersChain {
+	return HandlersChain{RequestIDHeader, AccessLog, Recovery(JSONRecovery), SecurityHeaders, Gzip}
+}
+
+// WebDefaults is the recommended middleware stack for server rendered HTML route groups.
+func WebDefaults() HandlersChain {
+	return HandlersChain{RequestIDHeader, AccessLog, Recovery(HTMLRecovery), SecurityHeaders, Gzip}
+}
@@ -0,0 +1,117 @@
+package goweb
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	metaConsumes = "goweb.consumes"
+	metaProduces = "goweb.produces"
+)
+
+// Consumes restricts the request bodies accepted by the route to the given media types, e.g. "application/json" or "multipart/*".
+// Requests with a body of another type are answered with 415 before any handler runs.
+func (r *Route) Consumes(mediaTypes ...string) *Route {
+	return r.Meta(metaConsumes, mediaTypes)
+}
+
+// Produces declares the media types the route responds with. Requests whose Accept header allows none of them are answered with 406
+// before any handler runs, the best match is available to the handler through Context.NegotiatedType.
+func (r *Route) Produces(mediaTypes ...string) *Route {
+	return r.Meta(metaProduces, mediaTypes)
+}
+
+// NegotiatedType returns the media type picked among those declared with Produces, or "" if the route declares none.
+func (c *Context) NegotiatedType() string {
+	return c.negotiated
+}
+
+// negotiate enforces the Consumes and Produces declarations of the matched route, it returns false if it answered the request.
+func (c *Context) negotiate() bool {
+	if consumes, _ := c.RouteMeta(metaConsumes).([]string); len(consumes) > 0 && hasBody(c.Request) {
+		mediaType, _, err := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+		if err != nil || !matchAnyMediaType(consumes, mediaType) {
+			c.AbortWithError(http.StatusUnsupportedMediaType, errors.New("unsupported content type, expected one of "+strings.Join(consumes, ", ")))
+			return false
+		}
+	}
+	if produces, _ := c.RouteMeta(metaProduces).([]string); len(produces) > 0 {
+		c.negotiated = negotiateType(c.Request.Header.Get("Accept"), produces)
+		if c.negotiated == "" {
+			c.AbortWithError(http.StatusNotAcceptable, errors.New("not acceptable, available types are "+strings.Join(produces, ", ")))
+			return false
+		}
+	}
+	return true
+}
+
+func hasBody(req *http.Request) bool {
+	return req.ContentLength > 0 || req.ContentLength == -1 && req.Body != nil && req.Body != http.NoBody
+}
+
+func matchAnyMediaType(patterns []string, mediaType string) bool {
+	for _, p := range patterns {
+		if mediaTypeMatches(p, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaTypeMatches reports whether mediaType falls within pattern, which may be */* or a type/* wildcard.
+func mediaTypeMatches(pattern, mediaType string) bool {
+	pattern, mediaType = strings.ToLower(pattern), strings.ToLower(mediaType)
+	if pattern == "*/*" || pattern == mediaType {
+		return true
+	}
+	return strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, pattern[:len(pattern)-1])
+}
+
+// negotiateType picks the offered type with the highest quality in the Accept header, the first offer wins ties and an empty header.
+// The quality of an offer is that of the most specific range matching it (RFC 9110 12.5.1), so text/html;q=0 excludes text/html
+// even with */* present, and offers with quality 0 are never picked.
+func negotiateType(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, specificity := 0.0, 0
+		for _, part := range strings.Split(accept, ",") {
+			mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || !mediaTypeMatches(mediaRange, offer) {
+				continue
+			}
+			s := mediaRangeSpecificity(mediaRange)
+			if s <= specificity {
+				continue
+			}
+			rangeQ := 1.0
+			if v, ok := params["q"]; ok {
+				if rangeQ, err = strconv.ParseFloat(v, 64); err != nil {
+					continue
+				}
+			}
+			q, specificity = rangeQ, s
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// mediaRangeSpecificity ranks */* below type/* below a full media type.
+func mediaRangeSpecificity(mediaRange string) int {
+	switch {
+	case mediaRange == "*/*":
+		return 1
+	case strings.HasSuffix(mediaRange, "/*"):
+		return 2
+	}
+	return 3
+}
@@ -0,0 +1,59 @@
+package goweb
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// UseBeforeRouting adds middleware run for every request before the route is looked up, so it can rewrite the request path.
+// A middleware calling Abort ends the request without routing. It runs once the request got a slot in ConcurrenceNumSem,
+// and its panics are recovered from like those of route handlers.
+func (engine *Engine) UseBeforeRouting(middleware ...HandlerFunc) {
+	engine.beforeRouting = append(engine.beforeRouting, middleware...)
+}
+
+type NormalizeOptions struct {
+	// Redirect answers non canonical paths with a 301 to the canonical one instead of rewriting the request in place.
+	Redirect bool
+	// RejectEncodedSlash answers paths containing an encoded slash (%2F) with 400 instead of treating it as a path separator.
+	RejectEncodedSlash bool
+}
+
+// NormalizePath returns a middleware for UseBeforeRouting that collapses duplicate slashes and resolves . and .. segments.
+func NormalizePath(opts NormalizeOptions) HandlerFunc {
+	return func(c *Context) {
+		u := c.Request.URL
+		if opts.RejectEncodedSlash && strings.Contains(strings.ToLower(u.RawPath), "%2f") {
+			c.AbortWithError(http.StatusBadRequest, errors.New("encoded slash in path"))
+			return
+		}
+		cleaned := cleanPath(u.Path)
+		if cleaned == u.Path {
+			return
+		}
+		if opts.Redirect {
+			target := *u
+			target.Path = cleaned
+			target.RawPath = ""
+			http.Redirect(c.Writer, c.Request, target.RequestURI(), http.StatusMovedPermanently)
+			c.Abort()
+			return
+		}
+		u.Path = cleaned
+		u.RawPath = ""
+	}
+}
+
+// cleanPath is path.Clean keeping a trailing slash.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
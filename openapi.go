@@ -0,0 +1,270 @@
+package goweb
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	metaSummary   = "goweb.summary"
+	metaTags      = "goweb.tags"
+	metaInput     = "goweb.input"
+	metaResponses = "goweb.responses"
+	metaHidden    = "goweb.hidden"
+)
+
+// OpenAPIInfo is the info object of the document produced by Engine.OpenAPI.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type openAPIResponse struct {
+	status      int
+	description string
+	body        reflect.Type
+}
+
+// Summary sets the one line description of the route in the OpenAPI document.
+func (r *Route) Summary(summary string) *Route {
+	return r.Meta(metaSummary, summary)
+}
+
+func (r *Route) Tags(tags ...string) *Route {
+	return r.Meta(metaTags, tags)
+}
+
+// Input documents the struct the handler binds with BindForm, its form tags become query parameters for GET and DELETE
+// routes and request body properties for the others.
+func (r *Route) Input(v interface{}) *Route {
+	return r.Meta(metaInput, reflect.TypeOf(v))
+}
+
+// Response documents a response of the route, body is a value of the type written as JSON, or nil for none.
+func (r *Route) Response(status int, description string, body interface{}) *Route {
+	var responses []openAPIResponse
+	if len(r.nodes) > 0 {
+		responses, _ = r.nodes[0].meta[metaResponses].([]openAPIResponse)
+	}
+	return r.Meta(metaResponses, append(responses, openAPIResponse{status: status, description: description, body: reflect.TypeOf(body)}))
+}
+
+// Hidden leaves the route out of the OpenAPI document.
+func (r *Route) Hidden() *Route {
+	return r.Meta(metaHidden, true)
+}
+
+// OpenAPI returns an OpenAPI 3 document describing the routes registered with a path, regular expression and matcher routes are left out.
+func (engine *Engine) OpenAPI() map[string]interface{} {
+	info := engine.OpenAPIInfo
+	if info.Title == "" {
+		info.Title = "API"
+	}
+	if info.Version == "" {
+		info.Version = "1.0.0"
+	}
+	paths := map[string]map[string]interface{}{}
+	for _, t := range engine.trees {
+		n := t.root
+		if n.path == "" || t.method == "" || t.method == http.MethodConnect || n.meta[metaHidden] == true {
+			continue
+		}
+		p, params := openAPIPath(n.path)
+		if paths[p] == nil {
+			paths[p] = map[string]interface{}{}
+		}
+		paths[p][strings.ToLower(t.method)] = openAPIOperation(t.method, n, params)
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    info,
+		"paths":   paths,
+	}
+}
+
+// openAPIPath turns /users/:id into /users/{id} and returns the path parameters.
+func openAPIPath(p string) (string, []interface{}) {
+	var params []interface{}
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			name := segment[1:]
+			segments[i] = "{" + name + "}"
+			params = append(params, map[string]interface{}{"name": name, "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"}})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func openAPIOperation(method string, n *node, params []interface{}) map[string]interface{} {
+	op := map[string]interface{}{}
+	if summary, ok := n.meta[metaSummary].(string); ok {
+		op["summary"] = summary
+	}
+	if tags, ok := n.meta[metaTags].([]string); ok {
+		op["tags"] = tags
+	}
+	if input, ok := n.meta[metaInput].(reflect.Type); ok {
+		schema := typeSchema(input, "form", map[reflect.Type]bool{})
+		if method == http.MethodGet || method == http.MethodDelete {
+			props, _ := schema["properties"].(map[string]interface{})
+			names := make([]string, 0, len(props))
+			for name := range props {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				params = append(params, map[string]interface{}{"name": name, "in": "query", "schema": props[name]})
+			}
+		} else {
+			consumes, _ := n.meta[metaConsumes].([]string)
+			if len(consumes) == 0 {
+				consumes = []string{"application/x-www-form-urlencoded", "multipart/form-data"}
+			}
+			content := map[string]interface{}{}
+			for _, mediaType := range consumes {
+				content[mediaType] = map[string]interface{}{"schema": schema}
+			}
+			op["requestBody"] = map[string]interface{}{"content": content}
+		}
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	produces, _ := n.meta[metaProduces].([]string)
+	if len(produces) == 0 {
+		produces = []string{"application/json"}
+	}
+	responses := map[string]interface{}{}
+	declared, _ := n.meta[metaResponses].([]openAPIResponse)
+	for _, r := range declared {
+		response := map[string]interface{}{"description": r.description}
+		if r.body != nil {
+			content := map[string]interface{}{}
+			for _, mediaType := range produces {
+				content[mediaType] = map[string]interface{}{"schema": typeSchema(r.body, "json", map[reflect.Type]bool{})}
+			}
+			response["content"] = content
+		}
+		responses[strconv.Itoa(r.status)] = response
+	}
+	if len(responses) == 0 {
+		responses["default"] = map[string]interface{}{"description": "response"}
+	}
+	op["responses"] = responses
+	return op
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// typeSchema describes t as a JSON schema, naming struct fields after the given struct tag.
+func typeSchema(t reflect.Type, tag string, seen map[reflect.Type]bool) map[string]interface{} {
+	if t == fileHeaderType {
+		return map[string]interface{}{"type": "string", "format": "binary"}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), tag, seen)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), tag, seen)}
+	case reflect.Struct:
+		if t == timeType {
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		}
+		if seen[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		props := map[string]interface{}{}
+		addStructProperties(t, tag, props, seen)
+		return map[string]interface{}{"type": "object", "properties": props}
+	}
+	return map[string]interface{}{}
+}
+
+func addStructProperties(t reflect.Type, tag string, props map[string]interface{}, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(sf.Tag.Get(tag), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct {
+			addStructProperties(sf.Type, tag, props, seen)
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		props[name] = typeSchema(sf.Type, tag, seen)
+	}
+}
+
+// OpenAPIHandler serves the document produced by Engine.OpenAPI as JSON.
+func OpenAPIHandler(c *Context) {
+	b, err := json.Marshal(c.Engine.OpenAPI())
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.writeBody(http.StatusOK, "application/json", b)
+}
+
+var swaggerUITemplate = template.Must(template.New("swagger").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>API documentation</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>SwaggerUIBundle({url: {{.}}, dom_id: "#swagger-ui"});</script>
+</body>
+</html>
+`))
+
+// OpenAPIDocs registers the document at prefix/openapi.json and, when swaggerUI is true, a Swagger UI page at prefix/docs.
+// The routes are left out of the document themselves.
+func (group *RouterGroup) OpenAPIDocs(prefix string, swaggerUI bool) {
+	specPath := path.Join(prefix, "openapi.json")
+	group.GET(specPath, OpenAPIHandler).Hidden()
+	if !swaggerUI {
+		return
+	}
+	group.GET(path.Join(prefix, "docs"), func(c *Context) {
+		c.Writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := swaggerUITemplate.Execute(c.Writer, group.calculatePath(specPath)); err != nil {
+			c.Engine.Logger.Println(err)
+		}
+	}).Hidden()
+}
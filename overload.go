@@ -0,0 +1,63 @@
+package goweb
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// OverloadStats describes how requests fared against the concurrency limit.
+type OverloadStats struct {
+	Admitted      int64
+	Rejected      int64
+	Bypassed      int64
+	Waiting       int64
+	TotalWaitTime time.Duration
+	MaxWaitTime   time.Duration
+}
+
+type overloadCounters struct {
+	admitted  int64
+	rejected  int64
+	bypassed  int64
+	waiting   int64
+	totalWait int64
+	maxWait   int64
+}
+
+func (oc *overloadCounters) admit(wait time.Duration) {
+	atomic.AddInt64(&oc.admitted, 1)
+	atomic.AddInt64(&oc.totalWait, int64(wait))
+	for {
+		max := atomic.LoadInt64(&oc.maxWait)
+		if int64(wait) <= max || atomic.CompareAndSwapInt64(&oc.maxWait, max, int64(wait)) {
+			return
+		}
+	}
+}
+
+// OverloadStats returns a snapshot of the concurrency limiter counters.
+func (engine *Engine) OverloadStats() OverloadStats {
+	oc := engine.overload
+	return OverloadStats{
+		Admitted:      atomic.LoadInt64(&oc.admitted),
+		Rejected:      atomic.LoadInt64(&oc.rejected),
+		Bypassed:      atomic.LoadInt64(&oc.bypassed),
+		Waiting:       atomic.LoadInt64(&oc.waiting),
+		TotalWaitTime: time.Duration(atomic.LoadInt64(&oc.totalWait)),
+		MaxWaitTime:   time.Duration(atomic.LoadInt64(&oc.maxWait)),
+	}
+}
+
+// OverloadFunc handles a request that waited longer than QueueTimeout for a free slot.
+// It returns true to have the request served anyway, or false once it has written a response.
+type OverloadFunc func(c *Context) bool
+
+func defaultOverloadHandler(c *Context) bool {
+	c.Writer.WriteHeader(http.StatusServiceUnavailable)
+	_, err := c.Writer.Write([]byte("server overload"))
+	if err != nil {
+		c.Engine.Logger.Println(err)
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package goweb
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// Pagination holds the paging parameters of a request, parsed from the page/per_page or cursor query parameters.
+type Pagination struct {
+	Page    int
+	PerPage int
+	Cursor  string
+	// Total is the total number of items, or -1 until SetTotal is called.
+	Total      int
+	NextCursor string
+	url        *url.URL
+}
+
+// Pagination parses page, per_page and cursor from the query string. per_page defaults to defaultPerPage and is capped at maxPerPage.
+func (c *Context) Pagination(defaultPerPage, maxPerPage int) *Pagination {
+	q := c.Request.URL.Query()
+	p := &Pagination{Page: 1, PerPage: defaultPerPage, Cursor: q.Get("cursor"), Total: -1, url: c.Request.URL}
+	if page, err := strconv.Atoi(q.Get("page")); err == nil && page > 0 {
+		p.Page = page
+	}
+	if perPage, err := strconv.Atoi(q.Get("per_page")); err == nil && perPage > 0 {
+		p.PerPage = perPage
+	}
+	if maxPerPage > 0 && p.PerPage > maxPerPage {
+		p.PerPage = maxPerPage
+	}
+	if p.PerPage <= 0 {
+		p.PerPage = 1
+	}
+	if maxPage := maxInt/p.PerPage + 1; p.Page > maxPage {
+		p.Page = maxPage
+	}
+	return p
+}
+
+// maxInt caps Page so that Offset cannot overflow.
+const maxInt = int(^uint(0) >> 1)
+
+func (p *Pagination) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+func (p *Pagination) Limit() int {
+	return p.PerPage
+}
+
+func (p *Pagination) SetTotal(total int) {
+	p.Total = total
+}
+
+func (p *Pagination) TotalPages() int {
+	if p.Total < 0 {
+		return -1
+	}
+	return (p.Total + p.PerPage - 1) / p.PerPage
+}
+
+func (p *Pagination) HasPrev() bool {
+	return p.Cursor == "" && p.Page > 1
+}
+
+func (p *Pagination) HasNext() bool {
+	if p.NextCursor != "" {
+		return true
+	}
+	return p.Total >= 0 && p.Page < p.TotalPages()
+}
+
+// PageURL returns the request URL with the page query parameter replaced.
+func (p *Pagination) PageURL(page int) string {
+	u := *p.url
+	q := u.Query()
+	q.Set("page", strconv.Itoa(page))
+	q.Set("per_page", strconv.Itoa(p.PerPage))
+	q.Del("cursor")
+	u.RawQuery = q.Encode()
+	return u.RequestURI()
+}
+
+func (p *Pagination) NextURL() string {
+	if p.NextCursor != "" {
+		u := *p.url
+		q := u.Query()
+		q.Set("cursor", p.NextCursor)
+		q.Set("per_page", strconv.Itoa(p.PerPage))
+		q.Del("page")
+		u.RawQuery = q.Encode()
+		return u.RequestURI()
+	}
+	if !p.HasNext() {
+		return ""
+	}
+	return p.PageURL(p.Page + 1)
+}
+
+func (p *Pagination) PrevURL() string {
+	if !p.HasPrev() {
+		return ""
+	}
+	return p.PageURL(p.Page - 1)
+}
+
+// Meta returns the pagination metadata in a form suitable for JSON responses and templates.
+func (p *Pagination) Meta() map[string]interface{} {
+	meta := map[string]interface{}{
+		"page":     p.Page,
+		"per_page": p.PerPage,
+		"has_prev": p.HasPrev(),
+		"has_next": p.HasNext(),
+		"prev_url": p.PrevURL(),
+		"next_url": p.NextURL(),
+	}
+	if p.Total >= 0 {
+		meta["total"] = p.Total
+		meta["total_pages"] = p.TotalPages()
+	}
+	if p.NextCursor != "" {
+		meta["next_cursor"] = p.NextCursor
+	}
+	return meta
+}
+
+// WriteHeaders sets the Link and X-Total-Count response headers.
+func (p *Pagination) WriteHeaders(w *ResponseWriter) {
+	if next := p.NextURL(); next != "" {
+		w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"next\"", next))
+	}
+	if prev := p.PrevURL(); prev != "" {
+		w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"prev\"", prev))
+	}
+	if p.Total >= 0 {
+		w.Header().Set("X-Total-Count", strconv.Itoa(p.Total))
+	}
+}
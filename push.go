@@ -0,0 +1,22 @@
+package goweb
+
+import "net/http"
+
+// Push implements http.Pusher, it returns http.ErrNotSupported when the connection does not support HTTP/2 server push.
+func (w *ResponseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Push initiates HTTP/2 server pushes of paths, it must be called before the response body is written.
+// Push is a no-op returning http.ErrNotSupported on connections without push support.
+func (c *Context) Push(paths ...string) error {
+	for _, p := range paths {
+		if err := c.Writer.Push(p, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
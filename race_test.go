@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package goweb
+
+func init() {
+	raceEnabled = true
+}
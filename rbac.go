@@ -0,0 +1,127 @@
+package goweb
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+)
+
+const (
+	metaRoles       = "goweb.roles"
+	metaPermissions = "goweb.permissions"
+)
+
+// RequireRoles restricts the route to callers holding at least one of roles, enforced by the Authorize middleware.
+func (r *Route) RequireRoles(roles ...string) *Route {
+	return r.Meta(metaRoles, roles)
+}
+
+// RequirePermissions restricts the route to callers granted every one of permissions by the roles they hold.
+func (r *Route) RequirePermissions(permissions ...string) *Route {
+	return r.Meta(metaPermissions, permissions)
+}
+
+// SetRoles records the roles of the caller, for auth middleware to call alongside SetUserID.
+func (c *Context) SetRoles(roles ...string) {
+	c.roles = roles
+}
+
+func (c *Context) Roles() []string {
+	return c.roles
+}
+
+func (c *Context) HasRole(role string) bool {
+	return containsString(c.roles, role)
+}
+
+// PolicyProvider maps a role to the permissions it grants.
+type PolicyProvider interface {
+	Permissions(role string) ([]string, error)
+}
+
+// StaticPolicy is a PolicyProvider backed by a fixed map from role to permissions.
+type StaticPolicy map[string][]string
+
+func (p StaticPolicy) Permissions(role string) ([]string, error) {
+	return p[role], nil
+}
+
+// PolicyFunc adapts a function to PolicyProvider.
+type PolicyFunc func(role string) ([]string, error)
+
+func (f PolicyFunc) Permissions(role string) ([]string, error) {
+	return f(role)
+}
+
+// SQLPolicy loads the permissions of a role from a database, Query takes the role as its only argument and returns one permission per row.
+type SQLPolicy struct {
+	DB    *sql.DB
+	Query string
+}
+
+func (p SQLPolicy) Permissions(role string) ([]string, error) {
+	rows, err := p.DB.Query(p.Query, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var permissions []string
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions, rows.Err()
+}
+
+// Authorize returns a middleware enforcing the RequireRoles and RequirePermissions metadata of the matched route against the roles set with SetRoles.
+// Callers without a user id or roles are answered with 401, callers lacking a role or permission with 403. Routes without requirements pass through.
+func Authorize(policy PolicyProvider) HandlerFunc {
+	return func(c *Context) {
+		roles, _ := c.RouteMeta(metaRoles).([]string)
+		permissions, _ := c.RouteMeta(metaPermissions).([]string)
+		if len(roles) == 0 && len(permissions) == 0 {
+			return
+		}
+		if c.UserID() == "" && len(c.roles) == 0 {
+			c.AbortWithError(http.StatusUnauthorized, errors.New("authentication required"))
+			return
+		}
+		if len(roles) > 0 && !c.hasAnyRole(roles) {
+			c.AbortWithError(http.StatusForbidden, errors.New("forbidden"))
+			return
+		}
+		if len(permissions) == 0 {
+			return
+		}
+		granted := map[string]bool{}
+		for _, role := range c.roles {
+			perms, err := policy.Permissions(role)
+			if err != nil {
+				c.Logger().Println("authorize:", err)
+				c.AbortWithError(http.StatusInternalServerError, errors.New("authorization failed"))
+				return
+			}
+			for _, perm := range perms {
+				granted[perm] = true
+			}
+		}
+		for _, perm := range permissions {
+			if !granted[perm] {
+				c.AbortWithError(http.StatusForbidden, errors.New("forbidden"))
+				return
+			}
+		}
+	}
+}
+
+func (c *Context) hasAnyRole(roles []string) bool {
+	for _, role := range roles {
+		if c.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package goweb
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+)
+
+// RecoveryFunc writes the response for a handler that panicked with err.
+type RecoveryFunc func(c *Context, err interface{})
+
+// Recovery returns a middleware making panics in the handlers after it answered by fn, so API and web route groups can recover differently.
+func Recovery(fn RecoveryFunc) HandlerFunc {
+	return func(c *Context) {
+		c.recovery = fn
+	}
+}
+
+// JSONRecovery answers panics with a 500 result written by the context's ResultSerializer.
+func JSONRecovery(c *Context, err interface{}) {
+	if c.Writer.Written() {
+		return
+	}
+	c.AbortWithError(http.StatusInternalServerError, errors.New(http.StatusText(http.StatusInternalServerError)))
+}
+
+// HTMLRecovery answers panics with the engine's error page.
+func HTMLRecovery(c *Context, err interface{}) {
+	if c.Writer.Written() {
+		return
+	}
+	c.ShowErrorPage(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+}
+
+var defaultErrorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Status}} {{.Msg}}</title></head>
+<body><h1>{{.Status}}</h1><p>{{.Msg}}</p></body>
+</html>`))
+
+func defaultErrorPageFunc(c *Context, status int, msg string) {
+	c.Writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	c.Writer.WriteHeader(status)
+	err := defaultErrorPage.Execute(c.Writer, map[string]interface{}{"Status": status, "Msg": msg})
+	if err != nil {
+		c.Engine.Logger.Println(err)
+	}
+}
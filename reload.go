@@ -0,0 +1,132 @@
+package goweb
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// reloadableSettings are the settings that may be replaced while the engine is serving requests.
+type reloadableSettings struct {
+	// config is the configuration the settings come from, ClientIP reads the trusted proxies from it.
+	config             *Config
+	maintenance        bool
+	maintenanceAllowed map[string]bool
+	blockedIPs         map[string]bool
+}
+
+// Reload replaces the runtime reloadable settings (maintenance mode, IP blocklist and trusted proxies) with the ones from cfg.
+// Other settings of cfg only take effect on restart.
+func (engine *Engine) Reload(cfg *Config) {
+	settings := &reloadableSettings{config: cfg, maintenance: cfg.Maintenance, maintenanceAllowed: map[string]bool{}, blockedIPs: map[string]bool{}}
+	for _, ip := range cfg.MaintenanceAllowedIPs {
+		settings.maintenanceAllowed[ip] = true
+	}
+	for _, ip := range cfg.BlockedIPs {
+		settings.blockedIPs[ip] = true
+	}
+	engine.reloadable.Store(settings)
+}
+
+// BlockIP adds ip to the blocklist until the settings are next reloaded.
+func (engine *Engine) BlockIP(ip string) {
+	engine.blockMu.Lock()
+	defer engine.blockMu.Unlock()
+	settings := &reloadableSettings{maintenanceAllowed: map[string]bool{}, blockedIPs: map[string]bool{ip: true}}
+	if old, _ := engine.reloadable.Load().(*reloadableSettings); old != nil {
+		settings.config = old.config
+		settings.maintenance = old.maintenance
+		settings.maintenanceAllowed = old.maintenanceAllowed
+		for blocked := range old.blockedIPs {
+			settings.blockedIPs[blocked] = true
+		}
+	}
+	engine.reloadable.Store(settings)
+}
+
+// ReloadConfig reads the config file the engine was configured from again and reloads its reloadable settings.
+func (engine *Engine) ReloadConfig() error {
+	current := engine.currentConfig()
+	if current == nil {
+		return errors.New("engine has no config to reload")
+	}
+	cfg, err := LoadConfig(current.path)
+	if err != nil {
+		return err
+	}
+	engine.Reload(cfg)
+	engine.Logger.Println("reloaded config", cfg.path)
+	return nil
+}
+
+// currentConfig returns the configuration last loaded with Reload, or else the one applied with ApplyConfig.
+func (engine *Engine) currentConfig() *Config {
+	if settings, _ := engine.reloadable.Load().(*reloadableSettings); settings != nil && settings.config != nil {
+		return settings.config
+	}
+	return engine.Config
+}
+
+// ReloadConfigHandler reloads the configuration, it is meant to be mounted behind authentication on an admin route.
+func ReloadConfigHandler(c *Context) {
+	if err := c.Engine.ReloadConfig(); err != nil {
+		c.Failed(err.Error())
+		return
+	}
+	c.Success(nil)
+}
+
+// rejectByRuntimeSettings answers requests from blocked IPs or made during maintenance, it returns true if it did.
+func (engine *Engine) rejectByRuntimeSettings(c *Context) bool {
+	settings, _ := engine.reloadable.Load().(*reloadableSettings)
+	if settings == nil {
+		return false
+	}
+	ip := c.ClientIP()
+	if settings.blockedIPs[ip] {
+		c.Writer.WriteHeader(http.StatusForbidden)
+		c.Writer.Write([]byte("forbidden"))
+		return true
+	}
+	if settings.maintenance && !settings.maintenanceAllowed[ip] && (engine.MaintenanceAllow == nil || !engine.MaintenanceAllow(c.Request)) {
+		c.Writer.Header().Set("Retry-After", "120")
+		c.Writer.WriteHeader(http.StatusServiceUnavailable)
+		c.Writer.Write([]byte("under maintenance"))
+		return true
+	}
+	return false
+}
+
+// ClientIP returns the address of the client, taken from X-Forwarded-For when the request comes through one of the configured trusted proxies.
+func (c *Context) ClientIP() string {
+	ip, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		ip = c.Request.RemoteAddr
+	}
+	cfg := c.Engine.currentConfig()
+	if cfg == nil || !containsString(cfg.TrustedProxies, ip) {
+		return ip
+	}
+	forwarded := strings.Split(c.Request.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if hop == "" {
+			continue
+		}
+		ip = hop
+		if !containsString(cfg.TrustedProxies, hop) {
+			break
+		}
+	}
+	return ip
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
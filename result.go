@@ -0,0 +1,93 @@
+package goweb
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// ResultSerializer writes the responses produced by Context.Success, Context.Failed and Context.AbortWithError.
+// status is 0 when the handler did not ask for a specific status code.
+type ResultSerializer interface {
+	WriteResult(c *Context, status int, data interface{}, err error)
+}
+
+// EnvelopeSerializer writes results as a JSON envelope, the zero value produces the same output as HandlerResult.
+type EnvelopeSerializer struct {
+	DataField  string
+	ErrorField string
+	// FailedStatus is the status code used for errors written without an explicit status, it defaults to 200.
+	FailedStatus int
+	// Bare writes successful results without the envelope.
+	Bare bool
+}
+
+func (s EnvelopeSerializer) WriteResult(c *Context, status int, data interface{}, err error) {
+	dataField, errorField := s.DataField, s.ErrorField
+	if dataField == "" {
+		dataField = "data"
+	}
+	if errorField == "" {
+		errorField = "error"
+	}
+	if status == 0 {
+		status = http.StatusOK
+		if err != nil && s.FailedStatus != 0 {
+			status = s.FailedStatus
+		}
+	}
+	var body []byte
+	if s.Bare && err == nil {
+		body = mustMarshal(data)
+	} else {
+		var errValue interface{}
+		if err != nil {
+			errValue = err.Error()
+		}
+		body = []byte("{" + string(mustMarshal(errorField)) + ":" + string(mustMarshal(errValue)) + "," + string(mustMarshal(dataField)) + ":" + string(mustMarshal(data)) + "}")
+	}
+	c.writeBody(status, "application/json", body)
+}
+
+// JSON writes v as a JSON response with the given status code.
+func (c *Context) JSON(status int, v interface{}) {
+	c.writeBody(status, "application/json", mustMarshal(v))
+}
+
+// writeBody writes a response whose body is fully known, so its Content-Length can be sent.
+func (c *Context) writeBody(status int, contentType string, body []byte) {
+	h := c.Writer.Header()
+	if h.Get("Content-Type") == "" {
+		h.Set("Content-Type", contentType)
+	}
+	if !c.Writer.compressing() && c.Writer.buf == nil && !c.Writer.wroteHeader {
+		h.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	c.Writer.WriteHeader(status)
+	c.Writer.Write(body)
+}
+
+// WithResultSerializer returns a middleware making the handlers after it use s, so a route group can have its own result format.
+func WithResultSerializer(s ResultSerializer) HandlerFunc {
+	return func(c *Context) {
+		c.serializer = s
+	}
+}
+
+func (c *Context) resultSerializer() ResultSerializer {
+	if c.serializer != nil {
+		return c.serializer
+	}
+	if c.Engine.ResultSerializer != nil {
+		return c.Engine.ResultSerializer
+	}
+	return EnvelopeSerializer{}
+}
+
+func mustMarshal(v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
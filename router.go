@@ -1,34 +1,204 @@
 package goweb
 
-import "regexp"
+import (
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
 
 type RouterGroup struct {
 	engine   *Engine
 	Handlers HandlersChain
+	basePath string
 }
 
-func (group *RouterGroup) Group() *RouterGroup {
+// Group returns a group inheriting the middleware of this one, prefix is prepended to the paths registered on it.
+func (group *RouterGroup) Group(prefix string) *RouterGroup {
 	return &RouterGroup{
-		Handlers: group.Handlers,
+		Handlers: group.combineHandlers(),
 		engine:   group.engine,
+		basePath: group.calculatePath(prefix),
 	}
 }
 
-func (group *RouterGroup) GET(path string, handler HandlerFunc) {
-	group.engine.trees = append(group.engine.trees, methodTree{"GET", &node{path: path, handlers: append(group.Handlers, handler)}})
+// BasePath returns the prefix of the paths registered on the group.
+func (group *RouterGroup) BasePath() string {
+	return group.basePath
 }
-func (group *RouterGroup) POST(path string, handler HandlerFunc) {
-	group.engine.trees = append(group.engine.trees, methodTree{"POST", &node{path: path, handlers: append(group.Handlers, handler)}})
+
+// calculatePath prepends the group prefix to relativePath, keeping a trailing slash.
+func (group *RouterGroup) calculatePath(relativePath string) string {
+	if relativePath == "" {
+		return group.basePath
+	}
+	if group.basePath == "" {
+		return relativePath
+	}
+	joined := path.Join(group.basePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(joined, "/") {
+		joined += "/"
+	}
+	return joined
+}
+
+// GET registers handlers for GET requests to path. Handlers before the last one act as middleware of this route only,
+// running after the group middleware, e.g. group.GET("/admin", RequireAdmin, admin). The other methods work alike.
+func (group *RouterGroup) GET(path string, handlers ...HandlerFunc) *Route {
+	return group.handle("GET", path, handlers)
+}
+func (group *RouterGroup) POST(path string, handlers ...HandlerFunc) *Route {
+	return group.handle("POST", path, handlers)
+}
+func (group *RouterGroup) PUT(path string, handlers ...HandlerFunc) *Route {
+	return group.handle("PUT", path, handlers)
+}
+func (group *RouterGroup) DELETE(path string, handlers ...HandlerFunc) *Route {
+	return group.handle("DELETE", path, handlers)
+}
+
+func (group *RouterGroup) PATCH(path string, handlers ...HandlerFunc) *Route {
+	return group.handle("PATCH", path, handlers)
 }
-func (group *RouterGroup) PUT(path string, handler HandlerFunc) {
-	group.engine.trees = append(group.engine.trees, methodTree{"PUT", &node{path: path, handlers: append(group.Handlers, handler)}})
+func (group *RouterGroup) HEAD(path string, handlers ...HandlerFunc) *Route {
+	return group.handle("HEAD", path, handlers)
 }
-func (group *RouterGroup) DELETE(path string, handler HandlerFunc) {
-	group.engine.trees = append(group.engine.trees, methodTree{"DELETE", &node{path: path, handlers: append(group.Handlers, handler)}})
+func (group *RouterGroup) OPTIONS(path string, handlers ...HandlerFunc) *Route {
+	return group.handle("OPTIONS", path, handlers)
 }
-func (group *RouterGroup) RegexMatch(regexp *regexp.Regexp, handler HandlerFunc) {
-	group.engine.trees = append(group.engine.trees, methodTree{"GET", &node{regexp: regexp, handlers: append(group.Handlers, handler)}})
+
+// anyMethods are the methods Any registers a handler for.
+var anyMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS", "CONNECT", "TRACE"}
+
+// Any registers handlers for path under every HTTP method.
+func (group *RouterGroup) Any(path string, handlers ...HandlerFunc) *Route {
+	route := &Route{}
+	for _, method := range anyMethods {
+		r := group.handle(method, path, handlers)
+		route.nodes = append(route.nodes, r.nodes...)
+	}
+	return route
+}
+
+// Mount serves every request to prefix or below it with h, whatever its method, after the group middleware. h sees the full request path,
+// as pprof, grpc-gateway and most handlers written for a shared mux expect, wrap it in http.StripPrefix for paths relative to prefix.
+func (group *RouterGroup) Mount(prefix string, h http.Handler) *Route {
+	handler := func(c *Context) {
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	route := group.Any(prefix+"/*path", handler)
+	if group.calculatePath(prefix) != "" {
+		route.nodes = append(route.nodes, group.Any(prefix, handler).nodes...)
+	}
+	return route
+}
+
+// RegexMatch registers a GET route for paths matching regexp, which is matched against the full path whatever the group prefix.
+// The capture groups are available through Context.Param, under their name for named groups and their index ("1", "2") otherwise.
+func (group *RouterGroup) RegexMatch(regexp *regexp.Regexp, handlers ...HandlerFunc) *Route {
+	return group.RegexMatchMethod("GET", regexp, handlers...)
+}
+
+// RegexMatchMethod is like RegexMatch for requests with the given method.
+func (group *RouterGroup) RegexMatchMethod(method string, regexp *regexp.Regexp, handlers ...HandlerFunc) *Route {
+	mustHaveHandlers(handlers, method+" "+regexp.String())
+	return group.addRoute(method, &node{regexp: regexp, handlers: group.combineHandlers(handlers...)})
 }
 func (group *RouterGroup) Use(middleware ...HandlerFunc) {
 	group.Handlers = append(group.Handlers, middleware...)
 }
+
+// combineHandlers copies the group handlers so routes registered on the same group never share a backing array.
+func (group *RouterGroup) combineHandlers(handlers ...HandlerFunc) HandlersChain {
+	merged := make(HandlersChain, 0, len(group.Handlers)+len(handlers))
+	merged = append(merged, group.Handlers...)
+	return append(merged, handlers...)
+}
+
+func (group *RouterGroup) handle(method, relativePath string, handlers HandlersChain) *Route {
+	mustHaveHandlers(handlers, method+" "+relativePath)
+	return group.addRoute(method, &node{path: group.calculatePath(relativePath), handlers: group.combineHandlers(handlers...)})
+}
+
+func mustHaveHandlers(handlers HandlersChain, route string) {
+	if len(handlers) == 0 {
+		panic("no handler given for route " + route)
+	}
+}
+
+// addRoute panics when the method already has a route for the same path or regular expression, paths differing only in the names
+// of their parameters being the same path, so conflicting registrations fail at startup rather than the first one silently winning.
+func (group *RouterGroup) addRoute(method string, n *node) *Route {
+	n.hasParams = strings.Contains(n.path, "/:") || strings.Contains(n.path, "/*")
+	if i := strings.Index(n.path, "/*"); i >= 0 && strings.Contains(n.path[i+1:], "/") {
+		panic("catch-all parameter must be the last segment of path " + n.path)
+	}
+	engine := group.engine
+	if strings.HasPrefix(n.path, "/") {
+		if engine.pathTrees == nil {
+			engine.pathTrees = map[string]*pathTree{}
+		}
+		if engine.pathTrees[method] == nil {
+			engine.pathTrees[method] = &pathTree{}
+		}
+		if existing := engine.pathTrees[method].insert(n); existing != nil {
+			panic("route " + method + " " + n.path + " conflicts with the route registered for " + existing.path)
+		}
+	} else if n.regexp == nil && n.matcher == nil {
+		// such a path can never match a request, as request paths always start with "/"
+		panic("path " + strconv.Quote(n.path) + " must begin with \"/\"")
+	} else {
+		for _, v := range engine.patterns {
+			if n.regexp != nil && v.method == method && v.root.regexp != nil && v.root.regexp.String() == n.regexp.String() {
+				panic("route " + method + " " + n.regexp.String() + " is registered twice")
+			}
+		}
+		engine.patterns = append(engine.patterns, methodTree{method, n})
+	}
+	engine.trees = append(engine.trees, methodTree{method, n})
+	return &Route{nodes: []*node{n}}
+}
+
+// Route is a registered route, it is used to attach metadata read by middleware through Context.RouteMeta.
+// A Route returned by Any stands for the routes of all methods.
+type Route struct {
+	nodes []*node
+}
+
+// Meta sets a metadata value on the route.
+func (r *Route) Meta(key string, value interface{}) *Route {
+	for _, n := range r.nodes {
+		if n.meta == nil {
+			n.meta = map[string]interface{}{}
+		}
+		n.meta[key] = value
+	}
+	return r
+}
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	// Method is "" for routes registered with Match, they take every method.
+	Method string `json:"method"`
+	// Path is the path of the route, or the regular expression of RegexMatch routes. It is "" for Match routes.
+	Path   string `json:"path"`
+	Regexp bool   `json:"regexp,omitempty"`
+	// Handler is the name of the last handler, Handlers those of the whole chain including the group middleware.
+	Handler  string   `json:"handler"`
+	Handlers []string `json:"handlers"`
+}
+
+// Routes lists the registered routes in registration order.
+func (engine *Engine) Routes() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(engine.trees))
+	for _, t := range engine.trees {
+		r := RouteInfo{Method: t.method, Path: t.root.pattern(), Regexp: t.root.regexp != nil, Handlers: handlerNames(t.root.handlers)}
+		if n := len(r.Handlers); n > 0 {
+			r.Handler = r.Handlers[n-1]
+		}
+		routes = append(routes, r)
+	}
+	return routes
+}
@@ -0,0 +1,177 @@
+package goweb
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+)
+
+// inheritedFDEnv carries the descriptor number of a listener handed over by a restarting parent process.
+const inheritedFDEnv = "GOWEB_LISTEN_FD"
+
+type server struct {
+	srv      *http.Server
+	listener net.Listener
+}
+
+// Run listens on addr and serves the engine until the process is asked to stop, shutting down gracefully.
+// Sending SIGUSR2 starts a new copy of the binary that takes over the listening socket before this one shuts down.
+func (engine *Engine) Run(addr string) error {
+	return engine.RunServer(&http.Server{Addr: addr})
+}
+
+func (engine *Engine) RunTLS(addr, certFile, keyFile string) error {
+	return engine.RunServer(&http.Server{Addr: addr}, certFile, keyFile)
+}
+
+// RunServer is like Run but uses the settings of srv, certFile and keyFile may be given to serve TLS.
+func (engine *Engine) RunServer(srv *http.Server, tlsFiles ...string) error {
+	if srv.Handler == nil {
+		srv.Handler = engine
+	}
+	if connCtx := srv.ConnContext; connCtx != nil {
+		srv.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+			return connContext(connCtx(ctx, c), c)
+		}
+	} else {
+		srv.ConnContext = connContext
+	}
+	if connState := srv.ConnState; connState != nil {
+		srv.ConnState = func(c net.Conn, state http.ConnState) {
+			engine.conns.connState(c, state)
+			connState(c, state)
+		}
+	} else {
+		srv.ConnState = engine.conns.connState
+	}
+	ln, err := engine.listen(srv.Addr)
+	if err != nil {
+		return err
+	}
+	engine.serverMu.Lock()
+	engine.server = &server{srv: srv, listener: ln}
+	engine.serverMu.Unlock()
+	serveErr := make(chan error, 1)
+	go func() {
+		if len(tlsFiles) == 2 {
+			serveErr <- srv.ServeTLS(ln, tlsFiles[0], tlsFiles[1])
+		} else {
+			serveErr <- srv.Serve(ln)
+		}
+	}()
+	if engine.Banner != "" {
+		engine.printBanner([]string{ln.Addr().String()}, len(tlsFiles) == 2 || srv.TLSConfig != nil)
+	} else {
+		engine.Logger.Println("listening on", ln.Addr())
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go engine.handleSignals(stop)
+	err = <-serveErr
+	if err == http.ErrServerClosed {
+		engine.shutdownWG.Wait()
+		return nil
+	}
+	return err
+}
+
+// listen prefers, in order, a listener handed over by a restarting parent, a socket passed by systemd socket activation and finally a new socket on addr.
+func (engine *Engine) listen(addr string) (net.Listener, error) {
+	if fd := os.Getenv(inheritedFDEnv); fd != "" {
+		os.Unsetenv(inheritedFDEnv)
+		n, err := strconv.Atoi(fd)
+		if err != nil {
+			return nil, err
+		}
+		engine.Logger.Println("using inherited listener", fd)
+		return fileListener(n)
+	}
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err == nil && pid == os.Getpid() {
+		n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+		if err == nil && n > 0 {
+			engine.Logger.Println("using systemd socket activation listener")
+			return fileListener(sdListenFDsStart)
+		}
+	}
+	if addr == "" {
+		addr = ":http"
+	}
+	return net.Listen("tcp", addr)
+}
+
+// sdListenFDsStart is the first file descriptor passed by systemd, see sd_listen_fds(3).
+const sdListenFDsStart = 3
+
+func fileListener(fd int) (net.Listener, error) {
+	f := os.NewFile(uintptr(fd), "listener")
+	defer f.Close()
+	return net.FileListener(f)
+}
+
+// OnShutdown registers fn to be called after the server has stopped accepting requests, fn should return once ctx is done.
+func (engine *Engine) OnShutdown(fn func(ctx context.Context)) {
+	engine.shutdownHooks = append(engine.shutdownHooks, fn)
+}
+
+// Shutdown stops accepting new connections, waits up to ShutdownTimeout for in-flight requests and then runs the shutdown hooks.
+func (engine *Engine) Shutdown() error {
+	engine.serverMu.Lock()
+	s := engine.server
+	engine.serverMu.Unlock()
+	if s == nil {
+		return errors.New("server is not running")
+	}
+	engine.shutdownWG.Add(1)
+	defer engine.shutdownWG.Done()
+	ctx, cancel := context.WithTimeout(context.Background(), engine.ShutdownTimeout)
+	defer cancel()
+	err := s.srv.Shutdown(ctx)
+	var wg sync.WaitGroup
+	for _, hook := range engine.shutdownHooks {
+		wg.Add(1)
+		go func(hook func(ctx context.Context)) {
+			defer wg.Done()
+			hook(ctx)
+		}(hook)
+	}
+	wg.Wait()
+	return err
+}
+
+// Restart starts a new instance of the running binary, hands it the listening socket and then shuts this instance down gracefully.
+func (engine *Engine) Restart() error {
+	engine.serverMu.Lock()
+	s := engine.server
+	engine.serverMu.Unlock()
+	if s == nil {
+		return errors.New("server is not running")
+	}
+	fl, ok := s.listener.(interface{ File() (*os.File, error) })
+	if !ok {
+		return errors.New("listener can not be inherited")
+	}
+	f, err := fl.File()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cmd := exec.Command(os.Args[0], os.Args[1:]...)
+	cmd.Env = append(os.Environ(), inheritedFDEnv+"=3")
+	cmd.ExtraFiles = []*os.File{f}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	engine.Logger.Println("started new process", cmd.Process.Pid, "handing over listener")
+	return engine.Shutdown()
+}
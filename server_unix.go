@@ -0,0 +1,40 @@
+//go:build !windows
+// +build !windows
+
+package goweb
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func (engine *Engine) handleSignals(stop chan struct{}) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR2, syscall.SIGHUP)
+	defer signal.Stop(sig)
+	for {
+		select {
+		case s := <-sig:
+			var err error
+			switch s {
+			case syscall.SIGHUP:
+				if err := engine.ReloadConfig(); err != nil {
+					engine.Logger.Println("reloading config:", err)
+				}
+				continue
+			case syscall.SIGUSR2:
+				err = engine.Restart()
+			default:
+				engine.Logger.Println("received", s, "shutting down")
+				err = engine.Shutdown()
+			}
+			if err != nil {
+				engine.Logger.Println(err)
+			}
+			return
+		case <-stop:
+			return
+		}
+	}
+}
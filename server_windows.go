@@ -0,0 +1,20 @@
+package goweb
+
+import (
+	"os"
+	"os/signal"
+)
+
+func (engine *Engine) handleSignals(stop chan struct{}) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	defer signal.Stop(sig)
+	select {
+	case s := <-sig:
+		engine.Logger.Println("received", s, "shutting down")
+		if err := engine.Shutdown(); err != nil {
+			engine.Logger.Println(err)
+		}
+	case <-stop:
+	}
+}
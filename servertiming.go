@@ -0,0 +1,52 @@
+package goweb
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+type serverTiming struct {
+	name string
+	dur  time.Duration
+	desc string
+}
+
+// ServerTiming records a backend timing phase, recorded phases are sent in the Server-Timing header once the response header is written.
+// Calling it again with the same name adds dur to that phase. It is safe to call from several goroutines, e.g. for fan-out calls through HTTPClient.
+func (c *Context) ServerTiming(name string, dur time.Duration, desc string) {
+	c.timingsMu.Lock()
+	defer c.timingsMu.Unlock()
+	for i := range c.timings {
+		if c.timings[i].name == name {
+			c.timings[i].dur += dur
+			return
+		}
+	}
+	c.timings = append(c.timings, serverTiming{name: name, dur: dur, desc: desc})
+}
+
+// StartTiming starts timing the phase name, the returned function stops it and records it with ServerTiming.
+func (c *Context) StartTiming(name string, desc string) func() {
+	start := time.Now()
+	return func() {
+		c.ServerTiming(name, time.Since(start), desc)
+	}
+}
+
+func (c *Context) writeServerTiming() {
+	c.timingsMu.Lock()
+	defer c.timingsMu.Unlock()
+	if len(c.timings) == 0 {
+		return
+	}
+	var metrics []string
+	for _, t := range c.timings {
+		metric := t.name + ";dur=" + strconv.FormatFloat(float64(t.dur)/float64(time.Millisecond), 'f', 2, 64)
+		if t.desc != "" {
+			metric += ";desc=" + strconv.Quote(t.desc)
+		}
+		metrics = append(metrics, metric)
+	}
+	c.Writer.Header().Add("Server-Timing", strings.Join(metrics, ", "))
+}
@@ -0,0 +1,75 @@
+package goweb
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ErrSlowClient is returned by response writes once a client stopped reading fast enough, see Engine.WriteStallTimeout.
+var ErrSlowClient = errors.New("client too slow, response aborted")
+
+type connContextKey struct{}
+
+// connContext is the ConnContext of servers started with RunServer, it makes the connection of a request available to the engine.
+func connContext(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, conn)
+}
+
+func requestConn(req *http.Request) net.Conn {
+	conn, _ := req.Context().Value(connContextKey{}).(net.Conn)
+	return conn
+}
+
+// guardsWrites reports whether writes of the response get a deadline, which requires the server to be started with RunServer.
+func (w *ResponseWriter) guardsWrites() bool {
+	if w.ctx.Engine.WriteStallTimeout <= 0 {
+		return false
+	}
+	if w.conn == nil {
+		w.conn = requestConn(w.ctx.Request)
+	}
+	return w.conn != nil
+}
+
+// armWriteDeadline gives the client WriteStallTimeout plus the time to take n bytes at MinWriteRate to accept the next write.
+func (w *ResponseWriter) armWriteDeadline(n int) bool {
+	if !w.guardsWrites() {
+		return false
+	}
+	d := w.ctx.Engine.WriteStallTimeout
+	if rate := w.ctx.Engine.MinWriteRate; rate > 0 {
+		d += time.Duration(int64(n) * int64(time.Second) / int64(rate))
+	}
+	w.conn.SetWriteDeadline(time.Now().Add(d))
+	return true
+}
+
+// writeBody writes b to the client, aborting the request if the client does not take it in time.
+func (w *ResponseWriter) writeBody(b []byte) (n int, err error) {
+	if w.stalled {
+		return 0, ErrSlowClient
+	}
+	if w.dump != nil {
+		w.dump.write(b)
+	}
+	guarded := w.armWriteDeadline(len(b))
+	if w.gz != nil {
+		n, err = w.gz.Write(b)
+	} else {
+		n, err = w.ResponseWriter.Write(b)
+	}
+	if ne, ok := err.(net.Error); ok && guarded && ne.Timeout() {
+		w.stalled = true
+		c := w.ctx
+		c.Engine.Logger.Println("aborting response to slow client", c.loggedAddr(), c.Request.URL.Path)
+		if c.Err == nil {
+			c.Err = ErrSlowClient
+		}
+		c.Abort()
+		return n, ErrSlowClient
+	}
+	return n, err
+}
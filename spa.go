@@ -0,0 +1,35 @@
+package goweb
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// SPA serves a single-page application from fsys under prefix: existing files are served as they are and every other path
+// falls back to index so the client side router can handle it. Routes registered with exact paths under prefix still take precedence.
+func (group *RouterGroup) SPA(prefix string, fsys fs.FS, index string) {
+	prefix = strings.TrimSuffix(group.calculatePath(prefix), "/")
+	files := http.FS(fsys)
+	group.RegexMatch(regexp.MustCompile("^"+regexp.QuoteMeta(prefix)+"(/.*)?$"), func(c *Context) {
+		name := path.Clean("/" + strings.TrimPrefix(c.Request.URL.Path, prefix))
+		if name != "/" {
+			if info, err := fs.Stat(fsys, strings.TrimPrefix(name, "/")); err == nil && !info.IsDir() {
+				if err := c.ServeFile(files, name, DefaultCachePolicy); err != nil {
+					panic(err)
+				}
+				return
+			}
+			// a missing asset is a real 404, only page navigations fall back to the index
+			if path.Ext(name) != "" {
+				c.Writer.WriteHeader(http.StatusNotFound)
+				return
+			}
+		}
+		if err := c.ServeFile(files, "/"+index, DefaultCachePolicy); err != nil {
+			panic(err)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package goweb
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Static serves the files below the directory root under relativePath, e.g. group.Static("/assets", "./public"), for GET and HEAD
+// with Range, Last-Modified and ETag support and the DefaultCachePolicy. A directory is served through its index.html, if any.
+func (group *RouterGroup) Static(relativePath, root string) *Route {
+	return group.serveFiles(relativePath, http.Dir(root))
+}
+
+// StaticFS is Static serving the files of fsys, such as an embed.FS, so binaries need no files next to them at runtime.
+// Use fs.Sub to serve a subdirectory of an embedded tree, e.g. fs.Sub(assets, "public").
+func (group *RouterGroup) StaticFS(relativePath string, fsys fs.FS) *Route {
+	return group.serveFiles(relativePath, http.FS(fsys))
+}
+
+// StaticFile serves the single file name at relativePath, e.g. group.StaticFile("/favicon.ico", "./favicon.ico").
+func (group *RouterGroup) StaticFile(relativePath, name string) *Route {
+	dir, file := http.Dir(filepath.Dir(name)), "/"+filepath.Base(name)
+	handler := func(c *Context) {
+		serveStaticFile(c, dir, file)
+	}
+	route := group.GET(relativePath, handler)
+	route.nodes = append(route.nodes, group.HEAD(relativePath, handler).nodes...)
+	return route
+}
+
+func (group *RouterGroup) serveFiles(relativePath string, files http.FileSystem) *Route {
+	handler := func(c *Context) {
+		serveStaticFile(c, files, path.Clean(c.Param("filepath")))
+	}
+	pattern := strings.TrimSuffix(relativePath, "/") + "/*filepath"
+	route := group.GET(pattern, handler)
+	route.nodes = append(route.nodes, group.HEAD(pattern, handler).nodes...)
+	return route
+}
+
+// serveStaticFile serves name from files, or the index.html of the directory name, answering missing files with 404.
+func serveStaticFile(c *Context, files http.FileSystem, name string) {
+	if f, err := files.Open(name); err == nil {
+		info, err := f.Stat()
+		f.Close()
+		if err == nil && info.IsDir() {
+			name = path.Join(name, "index.html")
+		}
+	}
+	err := c.ServeFile(files, name, DefaultCachePolicy)
+	switch {
+	case err == nil:
+	case errors.Is(err, os.ErrNotExist):
+		c.Err = err
+		c.Writer.WriteHeader(http.StatusNotFound)
+	default:
+		c.AbortWithError(http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Local keeps objects as files below Dir.
+type Local struct {
+	Dir string
+	// URLPrefix is where Dir is served, e.g. "/files" when it is mounted with a goweb Static route.
+	URLPrefix string
+	// SignURL signs download URLs, typically goweb's Engine.SignURL with VerifySignedURL on the serving route.
+	SignURL func(rawurl string, ttl time.Duration) (string, error)
+}
+
+func (l *Local) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "\x00") {
+		return "", errors.New("storage: invalid key " + key)
+	}
+	return filepath.Join(l.Dir, filepath.FromSlash(clean)), nil
+}
+
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	// write next to the destination and rename, so readers never see a partial file
+	f, err := ioutil.TempFile(filepath.Dir(name), ".put-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), name); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	name, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, ErrNotExist
+	}
+	return f, err
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (l *Local) SignedURL(key string, ttl time.Duration) (string, error) {
+	if l.SignURL == nil {
+		return "", errors.New("storage: Local has no SignURL function")
+	}
+	u := url.URL{Path: path.Join("/", l.URLPrefix, key)}
+	return l.SignURL(u.String(), ttl)
+}
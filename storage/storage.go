@@ -0,0 +1,21 @@
+// Package storage is a small abstraction over the places uploaded and generated files are kept: local disk or an S3 compatible object store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotExist is returned by Get for keys that have no object.
+var ErrNotExist = errors.New("storage: object does not exist")
+
+// Storage stores objects by key, keys are slash separated paths such as "avatars/42.png".
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL downloading the object without further credentials until ttl elapses.
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
@@ -0,0 +1,45 @@
+package goweb
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/swishcloud/goweb/storage"
+)
+
+// StorageUploadStore adapts a storage.Storage to UploadStore, files are stored under prefix with a random name keeping their extension.
+// The location of an uploaded file is its storage key.
+func StorageUploadStore(s storage.Storage, prefix string) UploadStore {
+	return storageUploadStore{s: s, prefix: prefix}
+}
+
+type storageUploadStore struct {
+	s      storage.Storage
+	prefix string
+}
+
+func (u storageUploadStore) Save(ctx context.Context, filename, contentType string, r io.Reader) (string, error) {
+	key := path.Join(u.prefix, uuid.New().String()+path.Ext(path.Base(filename)))
+	if err := u.s.Put(ctx, key, r, contentType); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+func (u storageUploadStore) Remove(ctx context.Context, location string) error {
+	return u.s.Delete(ctx, location)
+}
+
+// RedirectToStorage redirects the client to a signed download URL of the object stored under key, valid for ttl.
+func (c *Context) RedirectToStorage(s storage.Storage, key string, ttl time.Duration) {
+	u, err := s.SignedURL(key, ttl)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	http.Redirect(c.Writer, c.Request, u, http.StatusFound)
+}
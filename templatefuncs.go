@@ -0,0 +1,105 @@
+package goweb
+
+import (
+	"html/template"
+	"strconv"
+	"time"
+)
+
+// TemplateFuncs returns the functions available to templates rendered for the request: the built in ones, overridden by those
+// registered with Engine.SetFunc and then by those added with Context.SetFunc. Handlers parsing their own templates, such as mounted
+// sub-apps, pass them to template.Funcs to format times, sizes and the like exactly as RenderPage does.
+func (c *Context) TemplateFuncs() template.FuncMap {
+	funcs := template.FuncMap{
+		"formatTime":       c.formatTime,
+		"formatTimeString": c.formatTimeString,
+		"format_file_size": formatFileSize,
+		"remainingTime":    c.RemainingTime,
+		"formValue":        c.formValue,
+		"formError":        c.formError,
+		"hasFormError":     c.hasFormError,
+		"honeypot":         func() template.HTML { return "" },
+		"device":           c.Device,
+		"isMobile":         c.IsMobile,
+	}
+	c.Engine.funcsMu.RLock()
+	for name, fn := range c.Engine.funcs {
+		funcs[name] = fn
+	}
+	c.Engine.funcsMu.RUnlock()
+	for name, fn := range c.FuncMap {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// SetFunc registers a template function for every request, replacing any built in function of the same name.
+func (engine *Engine) SetFunc(name string, fn interface{}) {
+	engine.funcsMu.Lock()
+	defer engine.funcsMu.Unlock()
+	if engine.funcs == nil {
+		engine.funcs = template.FuncMap{}
+	}
+	engine.funcs[name] = fn
+}
+
+// SetFunc adds a template function for the rest of the request, replacing any function of the same name.
+func (c *Context) SetFunc(name string, fn interface{}) {
+	if c.FuncMap == nil {
+		if c.ownFuncMap == nil {
+			c.ownFuncMap = map[string]interface{}{}
+		}
+		c.FuncMap = c.ownFuncMap
+	}
+	c.FuncMap[name] = fn
+}
+
+// timezoneOffset is the client's offset from UTC in minutes, as reported by the tom cookie.
+func (c *Context) timezoneOffset() time.Duration {
+	tom := 0
+	cookie, err := c.Request.Cookie("tom")
+	if err == nil {
+		tom, err = strconv.Atoi(cookie.Value)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return time.Duration(int64(time.Minute) * int64(tom))
+}
+
+func (c *Context) formatTime(t time.Time, layout string) (string, error) {
+	if layout == "" {
+		layout = "01/02/2006 15:04"
+	}
+	t = t.Add(-c.timezoneOffset())
+	return t.Format(layout), nil
+}
+
+func (c *Context) formatTimeString(t_str string, layout string) (string, error) {
+	if layout == "" {
+		layout = "01/02/2006 15:04"
+	}
+	offset := c.timezoneOffset()
+	t, err := time.Parse(time.RFC3339Nano, t_str)
+	if err != nil {
+		panic(err)
+	}
+	t = t.Add(-offset)
+	return t.Format(layout), nil
+}
+
+func formatFileSize(sizeStr string) (string, error) {
+	size, err := strconv.ParseFloat(sizeStr, 64)
+	if err != nil {
+		return "", err
+	}
+	if size > 1024*1024*1024 {
+		return strconv.FormatFloat(size/1024/1024/1024, 'f', 2, 64) + " gb", nil
+	} else if size > 1024*1024 {
+		return strconv.FormatFloat(size/1024/1024, 'f', 2, 64) + " mb", nil
+	} else if size > 1024 {
+		return strconv.FormatFloat(size/1024, 'f', 2, 64) + " kb", nil
+	} else {
+		return strconv.FormatFloat(size, 'f', 0, 64) + " bytes", nil
+	}
+}
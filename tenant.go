@@ -0,0 +1,91 @@
+package goweb
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Tenant is the per-tenant configuration loaded by the Tenancy middleware.
+type Tenant struct {
+	ID string
+	// Theme is a directory searched first by RenderPage for the tenant's own version of a template file.
+	Theme string
+	// ProjectID is added to the lines written by Context.Logger.
+	ProjectID string
+	// Values holds application specific tenant resources such as a database handle.
+	Values map[string]interface{}
+}
+
+// TenantResolver extracts the tenant key from a request, ok is false when the request names no tenant.
+type TenantResolver func(c *Context) (key string, ok bool)
+
+type TenantLoader func(key string) (*Tenant, error)
+
+func TenantFromHost() TenantResolver {
+	return func(c *Context) (string, bool) {
+		host, _, err := net.SplitHostPort(c.Request.Host)
+		if err != nil {
+			host = c.Request.Host
+		}
+		return strings.ToLower(host), host != ""
+	}
+}
+
+func TenantFromHeader(name string) TenantResolver {
+	return func(c *Context) (string, bool) {
+		key := c.Request.Header.Get(name)
+		return key, key != ""
+	}
+}
+
+// TenantFromPathPrefix uses the first path segment as the tenant key, e.g. acme for /acme/orders.
+func TenantFromPathPrefix() TenantResolver {
+	return func(c *Context) (string, bool) {
+		segment := strings.SplitN(strings.TrimPrefix(c.Request.URL.Path, "/"), "/", 2)[0]
+		return segment, segment != ""
+	}
+}
+
+// Tenancy returns a middleware resolving and loading the tenant of each request, requests without a known tenant are answered with 404.
+func Tenancy(resolve TenantResolver, load TenantLoader) HandlerFunc {
+	return func(c *Context) {
+		key, ok := resolve(c)
+		if !ok {
+			c.AbortWithError(http.StatusNotFound, errors.New("unknown tenant"))
+			return
+		}
+		tenant, err := load(key)
+		if err != nil || tenant == nil {
+			c.Engine.Logger.Println("loading tenant", key, err)
+			c.AbortWithError(http.StatusNotFound, errors.New("unknown tenant"))
+			return
+		}
+		c.tenant = tenant
+		c.logger = nil
+	}
+}
+
+// Tenant returns the tenant loaded by the Tenancy middleware, or nil.
+func (c *Context) Tenant() *Tenant {
+	return c.tenant
+}
+
+// themedFiles replaces each template file with the tenant theme's version of it when one exists.
+func (c *Context) themedFiles(filenames []string) []string {
+	if c.tenant == nil || c.tenant.Theme == "" {
+		return filenames
+	}
+	themed := make([]string, len(filenames))
+	for i, name := range filenames {
+		themed[i] = name
+		candidate := filepath.Join(c.tenant.Theme, name)
+		if _, err := os.Stat(candidate); err == nil {
+			themed[i] = candidate
+		}
+	}
+	return themed
+}
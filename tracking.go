@@ -0,0 +1,22 @@
+package goweb
+
+// TrackingAllowed reports whether the client agreed to have identifying details such as its IP address and user id logged.
+// It is false when Engine.TrackingConsent says so, or with Engine.HonorDoNotTrack for requests sending DNT: 1 or Sec-GPC: 1.
+// Security records, such as audit entries and login lockouts, keep the IP address regardless.
+func (c *Context) TrackingAllowed() bool {
+	if c.Engine.TrackingConsent != nil && !c.Engine.TrackingConsent(c) {
+		return false
+	}
+	if c.Engine.HonorDoNotTrack && (c.Request.Header.Get("DNT") == "1" || c.Request.Header.Get("Sec-GPC") == "1") {
+		return false
+	}
+	return true
+}
+
+// loggedAddr is the client address as it may appear in logs, "-" for clients that opted out of tracking.
+func (c *Context) loggedAddr() string {
+	if !c.TrackingAllowed() {
+		return "-"
+	}
+	return c.Request.RemoteAddr
+}
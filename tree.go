@@ -1,13 +1,211 @@
 package goweb
 
-import "regexp"
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
 
+// methodTree is a registered route, Engine.trees lists them all in registration order.
 type methodTree struct {
 	method string
 	root   *node
 }
+
+// pathTree indexes the path routes of one method by segment, so lookups cost the length of the path rather than the number of routes.
+// Static segments take precedence over :name parameters and those over a *name catch-all, backtracking when a branch leads nowhere.
+type pathTree struct {
+	static   map[string]*pathTree
+	param    *pathTree
+	catchAll *node
+	route    *node
+}
+
+// insert adds the route n, or returns the route already registered for the same path, parameter names aside.
+func (t *pathTree) insert(n *node) *node {
+	cur := t
+	for _, seg := range strings.Split(n.path[1:], "/") {
+		switch {
+		case strings.HasPrefix(seg, "*"):
+			if cur.catchAll != nil {
+				return cur.catchAll
+			}
+			cur.catchAll = n
+			return nil
+		case strings.HasPrefix(seg, ":"):
+			if cur.param == nil {
+				cur.param = &pathTree{}
+			}
+			cur = cur.param
+		default:
+			if cur.static == nil {
+				cur.static = map[string]*pathTree{}
+			}
+			next := cur.static[seg]
+			if next == nil {
+				next = &pathTree{}
+				cur.static[seg] = next
+			}
+			cur = next
+		}
+	}
+	if cur.route != nil {
+		return cur.route
+	}
+	cur.route = n
+	return nil
+}
+
+// lookup finds the route for path, given without its leading slash.
+func (t *pathTree) lookup(path string) *node {
+	seg, rest := cutSegment(path)
+	if rest == "" {
+		if next := t.static[seg]; next != nil && next.route != nil {
+			return next.route
+		}
+		if seg != "" && t.param != nil && t.param.route != nil {
+			return t.param.route
+		}
+		return t.catchAll
+	}
+	if next := t.static[seg]; next != nil {
+		if n := next.lookup(rest[1:]); n != nil {
+			return n
+		}
+	}
+	if seg != "" && t.param != nil {
+		if n := t.param.lookup(rest[1:]); n != nil {
+			return n
+		}
+	}
+	return t.catchAll
+}
+
+// lookupFold is lookup ignoring the case of static segments, it returns path spelled the way the matching route is.
+func (t *pathTree) lookupFold(path string) (string, bool) {
+	seg, rest := cutSegment(path)
+	for _, key := range t.foldKeys(seg) {
+		next := t.static[key]
+		if rest == "" {
+			if next.route != nil {
+				return key, true
+			}
+		} else if fixed, ok := next.lookupFold(rest[1:]); ok {
+			return key + "/" + fixed, true
+		}
+	}
+	if seg != "" && t.param != nil {
+		if rest == "" {
+			if t.param.route != nil {
+				return seg, true
+			}
+		} else if fixed, ok := t.param.lookupFold(rest[1:]); ok {
+			return seg + "/" + fixed, true
+		}
+	}
+	return path, t.catchAll != nil
+}
+
+// foldKeys returns the static segments equal to seg ignoring case, seg itself first.
+func (t *pathTree) foldKeys(seg string) []string {
+	var keys []string
+	if _, ok := t.static[seg]; ok {
+		keys = append(keys, seg)
+	}
+	for key := range t.static {
+		if key != seg && strings.EqualFold(key, seg) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
 type node struct {
-	path      string
-	regexp *regexp.Regexp
-	handlers  HandlersChain
-}
\ No newline at end of file
+	path     string
+	regexp   *regexp.Regexp
+	matcher  Matcher
+	handlers HandlersChain
+	meta     map[string]interface{}
+	// hasParams is set for paths with :name or *name segments, they are matched segment by segment.
+	hasParams bool
+}
+
+// pattern returns what the route was registered with.
+func (n *node) pattern() string {
+	if n.regexp != nil {
+		return n.regexp.String()
+	}
+	return n.path
+}
+
+// Param is a named path parameter and the path segment it matched.
+type Param struct {
+	Key   string
+	Value string
+}
+
+type Params []Param
+
+// Get returns the value of the named parameter, or "" if there is none.
+func (ps Params) Get(name string) string {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// matchParams matches path against a pattern such as /users/:id/posts/:postID or /static/*filepath, returning the parameters on success.
+// A catch-all parameter receives the rest of the path including its leading slash.
+func matchParams(params Params, pattern, path string) (Params, bool) {
+	for {
+		pattern = strings.TrimPrefix(pattern, "/")
+		if !strings.HasPrefix(path, "/") {
+			return nil, false
+		}
+		pSeg, pRest := cutSegment(pattern)
+		if strings.HasPrefix(pSeg, "*") {
+			return append(params, Param{Key: pSeg[1:], Value: path}), true
+		}
+		path = path[1:]
+		seg, rest := cutSegment(path)
+		if strings.HasPrefix(pSeg, ":") {
+			if seg == "" {
+				return nil, false
+			}
+			params = append(params, Param{Key: pSeg[1:], Value: seg})
+		} else if pSeg != seg {
+			return nil, false
+		}
+		if pRest == "" || rest == "" {
+			return params, pRest == rest
+		}
+		pattern, path = pRest, rest
+	}
+}
+
+// cutSegment splits s at its first slash, the slash stays with the rest.
+func cutSegment(s string) (segment, rest string) {
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		return s[:i], s[i:]
+	}
+	return s, ""
+}
+
+// regexpParams turns the capture groups of a regular expression route into params, named by the group name or index.
+func regexpParams(re *regexp.Regexp, match []string) Params {
+	if len(match) < 2 {
+		return nil
+	}
+	names := re.SubexpNames()
+	params := make(Params, 0, len(match)-1)
+	for i := 1; i < len(match); i++ {
+		key := names[i]
+		if key == "" {
+			key = strconv.Itoa(i)
+		}
+		params = append(params, Param{Key: key, Value: match[i]})
+	}
+	return params
+}
@@ -0,0 +1,121 @@
+package goweb
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func routingEngine() *Engine {
+	engine := Default()
+	engine.Logger = log.New(ioutil.Discard, "", 0)
+	routes := []string{
+		"/users/new",
+		"/users/:id",
+		"/users/:id/posts",
+		"/users/:id/files/*path",
+		"/static/*filepath",
+		"/static/logo.png",
+		"/a/b/c",
+		"/a/:x/d",
+		"/a/*rest",
+	}
+	for _, route := range routes {
+		route := route
+		engine.GET(route, func(c *Context) {
+			var params []string
+			for _, p := range c.Params() {
+				params = append(params, p.Key+"="+p.Value)
+			}
+			c.Writer.Write([]byte(route + " " + strings.Join(params, ",")))
+		})
+	}
+	engine.POST("/form", func(c *Context) {})
+	return engine
+}
+
+func TestRoutePrecedence(t *testing.T) {
+	engine := routingEngine()
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/users/new", "/users/new "},
+		{"/users/42", "/users/:id id=42"},
+		{"/users/new/posts", "/users/:id/posts id=new"},
+		{"/users/42/files/a/b.txt", "/users/:id/files/*path id=42,path=/a/b.txt"},
+		{"/static/logo.png", "/static/logo.png "},
+		{"/static/css/site.css", "/static/*filepath filepath=/css/site.css"},
+		{"/static/", "/static/*filepath filepath=/"},
+		// static b leads nowhere for d, so the lookup backtracks to :x
+		{"/a/b/c", "/a/b/c "},
+		{"/a/b/d", "/a/:x/d x=b"},
+		// neither b nor :x match three segments, the catch-all does
+		{"/a/b/e/f", "/a/*rest rest=/b/e/f"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != 200 || w.Body.String() != tt.want {
+			t.Errorf("GET %s: got %d %q, want %q", tt.path, w.Code, w.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestRouteUnmatched(t *testing.T) {
+	engine := routingEngine()
+	engine.RedirectTrailingSlash = true
+	engine.AutoHEAD = true
+	engine.AutoOPTIONS = true
+	tests := []struct {
+		method   string
+		path     string
+		status   int
+		location string
+		allow    string
+	}{
+		{"GET", "/users/new/", 301, "/users/new", ""},
+		{"GET", "/users/42/posts/", 301, "/users/42/posts", ""},
+		{"POST", "/form/", 308, "/form", ""},
+		{"GET", "/missing", 404, "", ""},
+		{"DELETE", "/users/42", 405, "", "GET, HEAD, OPTIONS"},
+		{"GET", "/form", 405, "", "OPTIONS, POST"},
+		{"OPTIONS", "/users/42", 204, "", "GET, HEAD, OPTIONS"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.status || w.Header().Get("Location") != tt.location || w.Header().Get("Allow") != tt.allow {
+			t.Errorf("%s %s: got %d Location %q Allow %q, want %d %q %q", tt.method, tt.path,
+				w.Code, w.Header().Get("Location"), w.Header().Get("Allow"), tt.status, tt.location, tt.allow)
+		}
+	}
+}
+
+func TestAddRoutePanics(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+	}{
+		{"duplicate", []string{"/users/:id", "/users/:id"}},
+		{"duplicate with another parameter name", []string{"/users/:id", "/users/:name"}},
+		{"duplicate catch-all", []string{"/static/*a", "/static/*b"}},
+		{"no leading slash", []string{"users"}},
+		{"catch-all not last", []string{"/static/*path/more"}},
+	}
+	for _, tt := range tests {
+		engine := Default()
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: registering %v did not panic", tt.name, tt.paths)
+				}
+			}()
+			for _, p := range tt.paths {
+				engine.GET(p, func(c *Context) {})
+			}
+		}()
+	}
+}
@@ -0,0 +1,171 @@
+package goweb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// UploadStore receives the files of a streamed upload.
+type UploadStore interface {
+	// Save writes r to the store, returning where it was stored. It must not keep partial data when r fails.
+	// ctx is the context of the upload request.
+	Save(ctx context.Context, filename, contentType string, r io.Reader) (location string, err error)
+	// Remove deletes a saved file, it is called for the files already saved when an upload is aborted.
+	Remove(ctx context.Context, location string) error
+}
+
+// TempDirStore saves uploaded files as temporary files in Dir, the system temp directory when empty.
+type TempDirStore struct {
+	Dir string
+}
+
+func (s TempDirStore) Save(ctx context.Context, filename, contentType string, r io.Reader) (string, error) {
+	f, err := ioutil.TempFile(s.Dir, "upload-*"+filepath.Ext(filepath.Base(filename)))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func (s TempDirStore) Remove(ctx context.Context, location string) error {
+	return os.Remove(location)
+}
+
+// UploadOptions configures Context.StreamUpload.
+type UploadOptions struct {
+	Store UploadStore
+	// MaxFileSize caps the size of each file, 0 means no limit.
+	MaxFileSize int64
+	// MaxFiles caps the number of files, 0 means no limit.
+	MaxFiles int
+	// MaxValueSize caps the size of each non-file field, it defaults to 1MB.
+	MaxValueSize int64
+	// Progress is called as file data is received, returning an error aborts the upload.
+	Progress func(p UploadProgress) error
+}
+
+type UploadProgress struct {
+	Field    string
+	Filename string
+	// Received is the number of bytes of the current file received so far.
+	Received int64
+}
+
+// UploadedFile describes a file saved by StreamUpload.
+type UploadedFile struct {
+	Field       string
+	Filename    string
+	ContentType string
+	Size        int64
+	Location    string
+}
+
+var ErrUploadTooLarge = errors.New("uploaded file is too large")
+
+// StreamUpload reads a multipart/form-data request part by part, streaming files into opts.Store without holding them in memory.
+// The non-file fields are returned as values. When the upload fails or is aborted, the files already saved are removed.
+func (c *Context) StreamUpload(opts UploadOptions) (files []UploadedFile, values url.Values, err error) {
+	if opts.Store == nil {
+		opts.Store = TempDirStore{}
+	}
+	if opts.MaxValueSize == 0 {
+		opts.MaxValueSize = 1 << 20
+	}
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err != nil {
+			// an upload aborted by the client leaves the request context canceled, the cleanup must still run
+			ctx := c.Request.Context()
+			if ctx.Err() != nil {
+				ctx = context.Background()
+			}
+			for _, f := range files {
+				if rerr := opts.Store.Remove(ctx, f.Location); rerr != nil {
+					c.Logger().Println("upload cleanup:", rerr)
+				}
+			}
+			files = nil
+		}
+	}()
+	values = url.Values{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return files, values, nil
+		}
+		if err != nil {
+			return files, nil, err
+		}
+		field := part.FormName()
+		if part.FileName() == "" {
+			b, err := ioutil.ReadAll(io.LimitReader(part, opts.MaxValueSize+1))
+			if err != nil {
+				return files, nil, err
+			}
+			if int64(len(b)) > opts.MaxValueSize {
+				return files, nil, fmt.Errorf("form field %s is too large", field)
+			}
+			values.Add(field, string(b))
+			continue
+		}
+		if opts.MaxFiles > 0 && len(files) == opts.MaxFiles {
+			return files, nil, fmt.Errorf("too many files, at most %d are accepted", opts.MaxFiles)
+		}
+		r := &uploadReader{r: part, limit: opts.MaxFileSize, progress: opts.Progress, p: UploadProgress{Field: field, Filename: part.FileName()}}
+		contentType := part.Header.Get("Content-Type")
+		location, err := opts.Store.Save(c.Request.Context(), part.FileName(), contentType, r)
+		if err != nil {
+			if r.err != nil {
+				err = r.err
+			}
+			return files, nil, err
+		}
+		files = append(files, UploadedFile{Field: field, Filename: part.FileName(), ContentType: contentType, Size: r.p.Received, Location: location})
+	}
+}
+
+// uploadReader enforces the file size limit and reports progress, err remembers why it failed the read.
+type uploadReader struct {
+	r        io.Reader
+	limit    int64
+	progress func(p UploadProgress) error
+	p        UploadProgress
+	err      error
+}
+
+func (u *uploadReader) Read(b []byte) (int, error) {
+	if u.err != nil {
+		return 0, u.err
+	}
+	n, err := u.r.Read(b)
+	u.p.Received += int64(n)
+	if u.limit > 0 && u.p.Received > u.limit {
+		u.err = ErrUploadTooLarge
+		return 0, u.err
+	}
+	if u.progress != nil && n > 0 {
+		if perr := u.progress(u.p); perr != nil {
+			u.err = perr
+			return 0, u.err
+		}
+	}
+	return n, err
+}
@@ -1,5 +1,10 @@
 package goweb
 
+import (
+	"net/http"
+	"time"
+)
+
 type WidgetManager struct {
 	HandlerWidget HandlerWidget
 }
@@ -18,9 +23,39 @@ type DefaultHanderWidget struct {
 }
 
 func (w *DefaultHanderWidget) Pre_Process(ctx *Context) {
+	if !ctx.Engine.logging() {
+		return
+	}
 	ctx.Engine.Logger.Println("start processing request ->", ctx)
 }
 
 func (w *DefaultHanderWidget) Post_Process(ctx *Context) {
-	ctx.Engine.Logger.Println("end processing request ->", ctx)
+	if !ctx.Engine.logging() {
+		return
+	}
+	o := ctx.Outcome()
+	ctx.Engine.Logger.Println("end processing request ->", ctx, "route:", o.Route, "status:", o.Status, "duration:", o.Duration)
+}
+
+// RequestOutcome describes what happened to a request, for Post_Process to report on.
+type RequestOutcome struct {
+	// Route is the pattern of the matched route, e.g. /users/:id, or "" when no route matched.
+	Route    string
+	Matched  bool
+	Status   int
+	Duration time.Duration
+	Err      error
+	Panicked bool
+}
+
+// Outcome returns the outcome of the request so far, it is complete once the handlers have run, as in Post_Process.
+func (c *Context) Outcome() RequestOutcome {
+	o := RequestOutcome{Matched: c.route != nil, Status: c.StatusCode, Duration: c.Engine.Clock.Now().Sub(c.CT), Err: c.Err, Panicked: c.panicked}
+	if c.route != nil {
+		o.Route = c.route.pattern()
+	}
+	if o.Status == 0 && c.Writer.Written() {
+		o.Status = http.StatusOK
+	}
+	return o
 }